@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+// Snapshot is the last successful response for a repo.
+type Snapshot struct {
+	Repo      string               `json:"repo"`
+	FetchedAt time.Time            `json:"fetched_at"`
+	Runs      []github.WorkflowRun `json:"runs"`
+}
+
+// Cache keeps one snapshot per repo and filter so ghflow has something to show when
+// GitHub is unreachable.
+type Cache struct {
+	dir string
+}
+
+// Open opens the cache in the default state directory.
+func Open() (*Cache, error) {
+	dir, err := config.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	return OpenDir(filepath.Join(dir, "cache"))
+}
+
+// OpenDir opens a cache rooted at dir, creating it if needed.
+func OpenDir(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// path is where repo's snapshot lives. A branch or workflow filter sees
+// different runs than the whole repo, so each filter gets its own file.
+func (c *Cache) path(repo config.Repo) (string, error) {
+	ext := ".json"
+	if repo.Branch != "" || repo.Workflow != "" {
+		ext = "@" + url.QueryEscape(repo.Branch) + "@" + url.QueryEscape(repo.Workflow) + ext
+	}
+	return config.RepoFile(c.dir, repo.FullName(), ext)
+}
+
+// Load returns the snapshot for repo, or nil if none was saved.
+func (c *Cache) Load(repo config.Repo) (*Snapshot, error) {
+	path, err := c.path(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 -- path built by c.path, which rejects separators and dot segments
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// Save replaces the snapshot for repo.
+func (c *Cache) Save(repo config.Repo, runs []github.WorkflowRun, fetchedAt time.Time) error {
+	path, err := c.path(repo)
+	if err != nil {
+		return err
+	}
+	snap := Snapshot{Repo: repo.FullName(), FetchedAt: fetchedAt, Runs: runs}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so a reader never sees a half-written file. Each
+	// writer gets its own temp file, since the daemon and dashboards may
+	// save the same repo at once.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,130 @@
+// Package cli implements ghflow's headless subcommands.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+// Exit codes shared by the subcommands.
+const (
+	ExitOK      = 0
+	ExitFailure = 1 // A run failed
+	ExitError   = 2 // Bad usage, or ghflow couldn't do its job
+)
+
+// Command runs a subcommand with its arguments and returns the exit code.
+type Command func(args []string) int
+
+var commands = map[string]Command{
+	"ctl":           Ctl,
+	"daemon":        Daemon,
+	"report":        Report,
+	"serve-metrics": ServeMetrics,
+	"status":        Status,
+	"statusline":    Statusline,
+	"watch":         Watch,
+	"web":           Web,
+}
+
+// Lookup returns the subcommand called name.
+func Lookup(name string) (Command, bool) {
+	cmd, ok := commands[name]
+	return cmd, ok
+}
+
+// newFlagSet returns a flag set whose usage message starts with
+// "Usage: ghflow <synopsis>".
+func newFlagSet(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: ghflow %s\n\nFlags:\n", synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseError is the exit code for a flag parsing error, which the flag
+// package has already reported.
+func parseError(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+	return ExitError
+}
+
+func errorf(format string, args ...any) int {
+	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+	return ExitError
+}
+
+// setup loads the config and applies its API settings.
+func setup() (*config.Config, error) {
+	if err := github.CheckCLI(); err != nil {
+		return nil, err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	github.SetRequestTimeout(time.Duration(cfg.RequestTimeout) * time.Second)
+	return cfg, nil
+}
+
+// selectRepos picks the repos of profile (the current config if empty),
+// narrowed to the owner/name repos given. Repos that aren't configured
+// are looked at without a filter.
+func selectRepos(cfg *config.Config, profile string, names []string) ([]config.Repo, error) {
+	repos := cfg.Repos
+	if profile != "" {
+		p, err := config.LoadProfile(profile)
+		if err != nil {
+			return nil, fmt.Errorf("loading profile %q: %w", profile, err)
+		}
+		repos = p.Repos
+	}
+	if len(names) == 0 {
+		return repos, nil
+	}
+
+	var selected []config.Repo
+	for _, name := range names {
+		owner, repoName, ok := strings.Cut(name, "/")
+		if !ok || owner == "" || repoName == "" || strings.Contains(repoName, "/") {
+			return nil, fmt.Errorf("invalid repo %q: use owner/name", name)
+		}
+		found := config.Repo{Owner: owner, Name: repoName}
+		for _, r := range repos {
+			if strings.EqualFold(r.FullName(), name) {
+				found = r
+				break
+			}
+		}
+		selected = append(selected, found)
+	}
+	return selected, nil
+}
+
+// stringList is a flag that can be given more than once, or as a comma
+// separated list.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/control"
+	"github.com/thesimpledev/ghflow/internal/rpc"
+)
+
+// Ctl steers a running dashboard: any slash command, or the status and
+// run queries, whose results are printed as JSON.
+func Ctl(args []string) int {
+	fs := newFlagSet("ctl", "ctl [--socket path] <command> [args]\n\n"+
+		"Commands:\n"+
+		"  status               what each card shows\n"+
+		"  run [owner/name] ID  a run on the dashboard, with its jobs\n"+
+		"  add, remove, focus, refresh, load, ...\n"+
+		"                       any slash command, e.g. 'ghflow ctl focus owner/name'")
+	socket := fs.String("socket", "", "`path` of the dashboard's socket (default: the most recently started dashboard)")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return ExitError
+	}
+
+	client, err := dialDashboard(*socket)
+	if err != nil {
+		return errorf("%v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var result any
+	switch name, rest := fs.Arg(0), fs.Args()[1:]; name {
+	case "status":
+		var statuses []control.CardStatus
+		err = client.Call(ctx, control.MethodStatus, nil, &statuses)
+		result = statuses
+	case "run":
+		params, parseErr := runParams(rest)
+		if parseErr != nil {
+			return errorf("%v", parseErr)
+		}
+		var status control.RunStatus
+		err = client.Call(ctx, control.MethodRun, params, &status)
+		result = status
+	default:
+		err = client.Call(ctx, control.MethodCommand, control.CommandParams{Command: slashCommand(name, rest)}, nil)
+	}
+	if err != nil {
+		return errorf("%v", err)
+	}
+	if result == nil {
+		return ExitOK
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return errorf("%v", err)
+	}
+	return ExitOK
+}
+
+// dialDashboard connects to the dashboard at socket, or to the most
+// recently started one that's still running.
+func dialDashboard(socket string) (*rpc.Client, error) {
+	if socket != "" {
+		return rpc.Dial(socket)
+	}
+	sockets, err := control.Sockets()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range sockets {
+		if client, err := rpc.Dial(path); err == nil {
+			return client, nil
+		}
+	}
+	return nil, errors.New("no dashboard is running")
+}
+
+func runParams(args []string) (control.RunParams, error) {
+	var params control.RunParams
+	if len(args) == 2 {
+		params.Repo, args = args[0], args[1:]
+	}
+	if len(args) != 1 {
+		return params, errors.New("usage: ghflow ctl run [owner/name] ID")
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil {
+		return params, errors.New("invalid run ID " + args[0])
+	}
+	params.RunID = id
+	return params, nil
+}
+
+// slashCommand turns ctl's arguments into a slash command. Paths are made
+// absolute, since the dashboard may be running in another directory.
+func slashCommand(name string, args []string) string {
+	name = strings.TrimPrefix(name, "/")
+	switch name {
+	case "add", "export":
+		// The whole argument is the path, spaces and all
+		if len(args) > 0 {
+			args = []string{absPath(strings.Join(args, " "), name == "add")}
+		}
+	case "scan":
+		// As for add, but a trailing --depth stays an option
+		var opts []string
+		if n := len(args); n >= 2 && args[n-2] == "--depth" {
+			args, opts = args[:n-2], args[n-2:]
+		} else if n >= 1 && strings.HasPrefix(args[n-1], "--depth=") {
+			args, opts = args[:n-1], args[n-1:]
+		}
+		if len(args) > 0 {
+			args = append([]string{absPath(strings.Join(args, " "), true)}, opts...)
+		} else {
+			args = opts
+		}
+	}
+	if len(args) == 0 {
+		return "/" + name
+	}
+	return "/" + name + " " + strings.Join(args, " ")
+}
+
+// absPath makes path absolute, unless it's relative to the home directory
+// or, when mustExist is set, doesn't exist here.
+func absPath(path string, mustExist bool) string {
+	if strings.HasPrefix(path, "~") {
+		return path
+	}
+	if mustExist {
+		if _, err := os.Stat(path); err != nil {
+			return path
+		}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/daemon"
+	"github.com/thesimpledev/ghflow/internal/poll"
+	"github.com/thesimpledev/ghflow/internal/rpc"
+)
+
+// minDaemonInterval keeps the daemon, which polls on behalf of every
+// dashboard, from eating the API quota.
+const minDaemonInterval = 10 * time.Second
+
+// Daemon polls the repos for every dashboard and headless command of
+// this user, until interrupted.
+func Daemon(args []string) int {
+	fs := newFlagSet("daemon", "daemon [--interval 1m] [--active-interval 10s]")
+	interval := fs.Duration("interval", daemon.DefaultInterval, "how often to poll while nothing is running")
+	active := fs.Duration("active-interval", 0, "how often to poll while a run is queued or in progress (default poll_min_interval, or 10s)")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+
+	cfg, err := setup()
+	if err != nil {
+		return errorf("%v", err)
+	}
+	d, err := daemon.New(cfg)
+	if err != nil {
+		return errorf("%v", err)
+	}
+	d.SetIntervals(max(*interval, minDaemonInterval), *active)
+
+	path, err := daemon.SocketPath()
+	if err != nil {
+		return errorf("%v", err)
+	}
+	ln, err := rpc.Listen(path)
+	if err != nil {
+		return errorf("%v", err)
+	}
+	defer os.Remove(path)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "ghflow daemon listening on %s\n", path)
+	if err := d.Run(ctx, ln); err != nil {
+		return errorf("%v", err)
+	}
+	return ExitOK
+}
+
+// fetchResults gets the repos' runs from the daemon when one is running
+// and has polled them, and fetches the rest directly.
+func fetchResults(ctx context.Context, repos []config.Repo) []poll.Result {
+	results := make([]poll.Result, len(repos))
+	known := map[string]daemon.RepoState{}
+	if c, err := daemon.Dial(); err == nil {
+		callCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		states, err := c.State(callCtx, repos)
+		cancel()
+		c.Close()
+		if err == nil {
+			for _, s := range states {
+				if s.Error == "" && !s.FetchedAt.IsZero() {
+					known[daemon.Key(s.Repo)] = s
+				}
+			}
+		}
+	}
+
+	var missing []config.Repo
+	var missingIdx []int
+	for i, r := range repos {
+		if s, ok := known[daemon.Key(r)]; ok {
+			results[i] = poll.Result{Repo: r, Runs: s.Runs}
+			continue
+		}
+		missing = append(missing, r)
+		missingIdx = append(missingIdx, i)
+	}
+	if len(missing) > 0 {
+		for j, res := range poll.FetchAll(ctx, missing) {
+			results[missingIdx[j]] = res
+		}
+	}
+	return results
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/metrics"
+)
+
+// minMetricsInterval keeps a scrape-happy config from eating the API quota.
+const minMetricsInterval = 10 * time.Second
+
+// ServeMetrics polls the repos in the background and serves Prometheus
+// metrics about them until interrupted.
+func ServeMetrics(args []string) int {
+	fs := newFlagSet("serve-metrics", "serve-metrics [--listen :9464] [--interval 1m] [--profile name] [--repo owner/name]")
+	listen := fs.String("listen", ":9464", "`address` to serve /metrics on")
+	interval := fs.Duration("interval", time.Minute, "how often to poll the repos")
+	profile := fs.String("profile", "", "use the repos of a saved `profile` instead of the current ones")
+	var repos stringList
+	fs.Var(&repos, "repo", "only export `owner/name` (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if *interval < minMetricsInterval {
+		*interval = minMetricsInterval
+	}
+
+	cfg, err := setup()
+	if err != nil {
+		return errorf("%v", err)
+	}
+	selected, err := selectRepos(cfg, *profile, repos)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	exporter := metrics.NewExporter(selected)
+	if err := serve(ctx, *listen, exporter); err != nil {
+		return errorf("%v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Serving metrics for %d repos on %s/metrics\n", len(selected), *listen)
+	exporter.Run(ctx, *interval)
+	return ExitOK
+}
+
+// serve listens on addr and serves handler until ctx is done. It returns
+// once the listener is bound.
+func serve(ctx context.Context, addr string, handler http.Handler) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/thesimpledev/ghflow/internal/report"
+)
+
+// Report writes a snapshot of the repos: status, recent runs, failing jobs
+// and success rates.
+func Report(args []string) int {
+	fs := newFlagSet("report", "report [--format md|html|csv] [--output file] [--profile name] [--repo owner/name]")
+	format := fs.String("format", "", "output `format`: md, html or csv (default from --output, else md)")
+	output := fs.String("output", "", "write to `file` instead of stdout")
+	profile := fs.String("profile", "", "use the repos of a saved `profile` instead of the current ones")
+	var repos stringList
+	fs.Var(&repos, "repo", "only report on `owner/name` (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if *format == "" {
+		*format = report.FormatFromPath(*output)
+	}
+	if !slices.Contains(report.Formats, *format) {
+		return errorf("unknown format %q: use %s", *format, strings.Join(report.Formats, ", "))
+	}
+
+	cfg, err := setup()
+	if err != nil {
+		return errorf("%v", err)
+	}
+	selected, err := selectRepos(cfg, *profile, repos)
+	if err != nil {
+		return errorf("%v", err)
+	}
+	name := *profile
+	if name == "" {
+		name = cfg.ProfileName
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	r := report.Build(ctx, report.Title(name), fetchResults(ctx, selected))
+	if *output == "" {
+		if err := report.Write(os.Stdout, *format, r); err != nil {
+			return errorf("%v", err)
+		}
+		return ExitOK
+	}
+	if err := report.WriteFile(*output, *format, r); err != nil {
+		return errorf("%v", err)
+	}
+	return ExitOK
+}
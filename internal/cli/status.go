@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/poll"
+)
+
+// RepoStatus is one repo's latest run, as printed by `ghflow status`.
+type RepoStatus struct {
+	Repo       string    `json:"repo"`
+	Status     string    `json:"status"`
+	Workflow   string    `json:"workflow,omitempty"`
+	Branch     string    `json:"branch,omitempty"`
+	RunNumber  int       `json:"run_number,omitempty"`
+	Conclusion string    `json:"conclusion,omitempty"`
+	Actor      string    `json:"actor,omitempty"`
+	URL        string    `json:"url,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at,omitzero"`
+	Error      string    `json:"error,omitempty"`
+}
+
+func newRepoStatus(r poll.Result) RepoStatus {
+	s := RepoStatus{Repo: r.Repo.FullName(), Status: string(r.Status())}
+	if r.Err != nil {
+		s.Status = "error"
+		s.Error = r.Err.Error()
+		return s
+	}
+	if len(r.Runs) > 0 {
+		run := r.Runs[0]
+		s.Workflow = run.WorkflowName
+		s.Branch = run.HeadBranch
+		s.RunNumber = run.RunNumber
+		s.Conclusion = run.Conclusion
+		s.Actor = run.Actor.Login
+		s.URL = run.HTMLURL
+		s.UpdatedAt = run.UpdatedAt
+	}
+	return s
+}
+
+// Status prints the latest run of each repo. It exits 1 if any latest
+// run failed and 2 if a repo couldn't be fetched.
+func Status(args []string) int {
+	fs := newFlagSet("status", "status [--profile name] [--repo owner/name] [--format table|json|ndjson]")
+	profile := fs.String("profile", "", "use the repos of a saved `profile` instead of the current ones")
+	var repos stringList
+	fs.Var(&repos, "repo", "only show `owner/name` (repeatable)")
+	format := fs.String("format", "table", "output `format`: table, json or ndjson")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	switch *format {
+	case "table", "json", "ndjson":
+	default:
+		return errorf("unknown format %q: use table, json or ndjson", *format)
+	}
+
+	cfg, err := setup()
+	if err != nil {
+		return errorf("%v", err)
+	}
+	selected, err := selectRepos(cfg, *profile, repos)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	results := fetchResults(ctx, selected)
+	statuses := make([]RepoStatus, len(results))
+	code := ExitOK
+	for i, r := range results {
+		statuses[i] = newRepoStatus(r)
+		switch {
+		case r.Err != nil:
+			code = ExitError
+		case r.Status() == github.StatusFailure && code == ExitOK:
+			code = ExitFailure
+		}
+	}
+
+	if err := writeStatuses(os.Stdout, *format, statuses); err != nil {
+		return errorf("%v", err)
+	}
+	return code
+}
+
+func writeStatuses(w io.Writer, format string, statuses []RepoStatus) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(statuses)
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, s := range statuses {
+			if err := enc.Encode(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPO\tSTATUS\tWORKFLOW\tBRANCH\tRUN\tUPDATED")
+	for _, s := range statuses {
+		if s.Error != "" {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t\t\t\n", s.Repo, s.Status, s.Error)
+			continue
+		}
+		run, updated := "", ""
+		if s.RunNumber > 0 {
+			run = fmt.Sprintf("#%d", s.RunNumber)
+		}
+		if !s.UpdatedAt.IsZero() {
+			updated = s.UpdatedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Repo, s.Status, s.Workflow, s.Branch, run, updated)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/cache"
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/poll"
+)
+
+// Summary is what a status line template is executed with.
+type Summary struct {
+	Total   int
+	Passing int
+	Failing int
+	Running int
+	Pending int
+	Unknown int // No runs, or cancelled
+	Errors  int // Couldn't be fetched and nothing cached
+
+	FailingRepos []string
+	RunningRepos []string
+
+	// Status is the worst of the repos: failure, in_progress, pending,
+	// success or unknown.
+	Status string
+}
+
+// Templates of the text presets.
+var statuslinePresets = map[string]string{
+	"plain": `{{.Failing}}✗ {{.Running}}~ {{.Passing}}✓`,
+	"tmux": `{{if .Failing}}#[fg=red]{{.Failing}}✗#[default] {{end}}` +
+		`{{if .Running}}#[fg=yellow]{{.Running}}~#[default] {{end}}` +
+		`#[fg=green]{{.Passing}}✓#[default]`,
+}
+
+// Statusline prints a one-line summary of the repos for a shell prompt or
+// status bar. Runs are read from the cache the dashboard keeps, and only
+// fetched when they're older than --max-age, so it's cheap to call every
+// few seconds.
+func Statusline(args []string) int {
+	fs := newFlagSet("statusline", "statusline [--preset plain|tmux|waybar|i3blocks] [--format template] [--max-age 30s] [--profile name] [--repo owner/name]")
+	preset := fs.String("preset", "plain", "output `preset`: plain, tmux, waybar or i3blocks")
+	format := fs.String("format", "", "Go `template` for the text, e.g. '{{.Failing}}✗ {{.Running}}~'")
+	maxAge := fs.Duration("max-age", 30*time.Second, "refetch repos whose cached runs are older than this")
+	profile := fs.String("profile", "", "use the repos of a saved `profile` instead of the current ones")
+	var repos stringList
+	fs.Var(&repos, "repo", "only count `owner/name` (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+
+	tmpl, err := statuslineTemplate(*preset, *format)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	// No gh check: with a warm cache nothing runs gh at all, and a
+	// status bar is better off showing an error count than nothing
+	cfg, err := config.Load()
+	if err != nil {
+		return errorf("loading config: %v", err)
+	}
+	github.SetRequestTimeout(time.Duration(cfg.RequestTimeout) * time.Second)
+	selected, err := selectRepos(cfg, *profile, repos)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	summary := summarize(cachedResults(ctx, selected, *maxAge, time.Now()))
+
+	if err := writeStatusline(os.Stdout, *preset, tmpl, summary); err != nil {
+		return errorf("%v", err)
+	}
+	return ExitOK
+}
+
+// statuslineTemplate parses format, or the text of preset when format is
+// empty. The JSON presets use the plain text.
+func statuslineTemplate(preset, format string) (*template.Template, error) {
+	text := format
+	switch preset {
+	case "plain", "tmux":
+		if text == "" {
+			text = statuslinePresets[preset]
+		}
+	case "waybar", "i3blocks":
+		if text == "" {
+			text = statuslinePresets["plain"]
+		}
+	default:
+		return nil, fmt.Errorf("unknown preset %q: use plain, tmux, waybar or i3blocks", preset)
+	}
+	tmpl, err := template.New("statusline").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %v", err)
+	}
+	return tmpl, nil
+}
+
+// cachedResults returns each repo's runs from the cache when they're
+// fresh enough, fetching and caching the rest. A repo that can't be
+// fetched falls back to whatever is cached, however old.
+func cachedResults(ctx context.Context, repos []config.Repo, maxAge time.Duration, now time.Time) []poll.Result {
+	results := make([]poll.Result, len(repos))
+	snapshots, err := cache.Open()
+	if err != nil {
+		snapshots = nil
+	}
+
+	var stale []config.Repo
+	var staleIdx []int
+	cached := make([]*cache.Snapshot, len(repos))
+	for i, r := range repos {
+		results[i].Repo = r
+		if snapshots != nil {
+			cached[i], _ = snapshots.Load(r)
+		}
+		if cached[i] != nil && now.Sub(cached[i].FetchedAt) < maxAge {
+			results[i].Runs = cached[i].Runs
+			continue
+		}
+		stale = append(stale, r)
+		staleIdx = append(staleIdx, i)
+	}
+	if len(stale) == 0 {
+		return results
+	}
+
+	for j, res := range fetchResults(ctx, stale) {
+		i := staleIdx[j]
+		if res.Err != nil {
+			if cached[i] != nil {
+				res.Runs, res.Err = cached[i].Runs, nil
+			}
+		} else if snapshots != nil {
+			_ = snapshots.Save(res.Repo, res.Runs, now)
+		}
+		results[i] = res
+	}
+	return results
+}
+
+func summarize(results []poll.Result) Summary {
+	s := Summary{Total: len(results)}
+	for _, r := range results {
+		if r.Err != nil {
+			s.Errors++
+			continue
+		}
+		switch r.Status() {
+		case github.StatusSuccess:
+			s.Passing++
+		case github.StatusFailure:
+			s.Failing++
+			s.FailingRepos = append(s.FailingRepos, r.Repo.FullName())
+		case github.StatusInProgress:
+			s.Running++
+			s.RunningRepos = append(s.RunningRepos, r.Repo.FullName())
+		case github.StatusPending:
+			s.Pending++
+		default:
+			s.Unknown++
+		}
+	}
+
+	switch {
+	case s.Failing > 0:
+		s.Status = string(github.StatusFailure)
+	case s.Running > 0:
+		s.Status = string(github.StatusInProgress)
+	case s.Pending > 0:
+		s.Status = string(github.StatusPending)
+	case s.Passing > 0:
+		s.Status = string(github.StatusSuccess)
+	default:
+		s.Status = string(github.StatusUnknown)
+	}
+	return s
+}
+
+// tooltip lists what's failing and running, one repo per line.
+func (s Summary) tooltip() string {
+	var lines []string
+	for _, r := range s.FailingRepos {
+		lines = append(lines, "✗ "+r)
+	}
+	for _, r := range s.RunningRepos {
+		lines = append(lines, "~ "+r)
+	}
+	if s.Errors > 0 {
+		lines = append(lines, fmt.Sprintf("%d repos couldn't be fetched", s.Errors))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, fmt.Sprintf("%d of %d repos passing", s.Passing, s.Total))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func writeStatusline(w io.Writer, preset string, tmpl *template.Template, s Summary) error {
+	var text strings.Builder
+	if err := tmpl.Execute(&text, s); err != nil {
+		return err
+	}
+
+	switch preset {
+	case "waybar":
+		return json.NewEncoder(w).Encode(struct {
+			Text    string `json:"text"`
+			Tooltip string `json:"tooltip"`
+			Class   string `json:"class"`
+			Alt     string `json:"alt"`
+		}{text.String(), s.tooltip(), s.Status, s.Status})
+	case "i3blocks":
+		return json.NewEncoder(w).Encode(struct {
+			FullText  string `json:"full_text"`
+			ShortText string `json:"short_text"`
+			Color     string `json:"color,omitempty"`
+		}{text.String(), fmt.Sprintf("%d✗", s.Failing), statusColor(s.Status)})
+	}
+	_, err := fmt.Fprintln(w, text.String())
+	return err
+}
+
+func statusColor(status string) string {
+	switch github.RunStatus(status) {
+	case github.StatusFailure:
+		return "#FF0000"
+	case github.StatusInProgress:
+		return "#FFAF00"
+	case github.StatusSuccess:
+		return "#00D787"
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/poll"
+)
+
+func TestSummarize(t *testing.T) {
+	result := func(name, status, conclusion string) poll.Result {
+		return poll.Result{
+			Repo: config.Repo{Owner: "acme", Name: name},
+			Runs: []github.WorkflowRun{{Status: status, Conclusion: conclusion}},
+		}
+	}
+	results := []poll.Result{
+		result("api", "completed", "success"),
+		result("web", "completed", "failure"),
+		result("cli", "in_progress", ""),
+		result("docs", "waiting", ""),
+		result("old", "completed", "cancelled"),
+		{Repo: config.Repo{Owner: "acme", Name: "new"}},
+		{Repo: config.Repo{Owner: "acme", Name: "gone"}, Err: errors.New("not found")},
+	}
+	want := Summary{
+		Total: 7, Passing: 1, Failing: 1, Running: 1, Pending: 1, Unknown: 2, Errors: 1,
+		FailingRepos: []string{"acme/web"},
+		RunningRepos: []string{"acme/cli"},
+		Status:       "failure",
+	}
+	if got := summarize(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("summarize() = %+v, want %+v", got, want)
+	}
+
+	if got := summarize(results[:1]).Status; got != "success" {
+		t.Errorf("summarize(passing).Status = %q, want success", got)
+	}
+	if got := summarize(nil).Status; got != "unknown" {
+		t.Errorf("summarize(nil).Status = %q, want unknown", got)
+	}
+}
+
+func TestWriteStatusline(t *testing.T) {
+	failing := Summary{
+		Total: 5, Passing: 2, Failing: 1, Running: 1, Errors: 1,
+		FailingRepos: []string{"acme/web"},
+		RunningRepos: []string{"acme/cli"},
+		Status:       "failure",
+	}
+	passing := Summary{Total: 3, Passing: 3, Status: "success"}
+	empty := Summary{Status: "unknown"}
+
+	tests := []struct {
+		name    string
+		preset  string
+		format  string
+		summary Summary
+		want    string
+	}{
+		{"plain", "plain", "", failing, "1✗ 1~ 2✓\n"},
+		{"tmux", "tmux", "", failing,
+			"#[fg=red]1✗#[default] #[fg=yellow]1~#[default] #[fg=green]2✓#[default]\n"},
+		{"tmux passing", "tmux", "", passing, "#[fg=green]3✓#[default]\n"},
+		{"waybar", "waybar", "", failing,
+			`{"text":"1✗ 1~ 2✓","tooltip":"✗ acme/web\n~ acme/cli\n1 repos couldn't be fetched","class":"failure","alt":"failure"}` + "\n"},
+		{"waybar passing", "waybar", "", passing,
+			`{"text":"0✗ 0~ 3✓","tooltip":"3 of 3 repos passing","class":"success","alt":"success"}` + "\n"},
+		{"i3blocks", "i3blocks", "", failing,
+			`{"full_text":"1✗ 1~ 2✓","short_text":"1✗","color":"#FF0000"}` + "\n"},
+		{"i3blocks without color", "i3blocks", "", empty,
+			`{"full_text":"0✗ 0~ 0✓","short_text":"0✗"}` + "\n"},
+		{"format", "plain", `{{.Total}}: {{join .FailingRepos ","}}`, failing, "5: acme/web\n"},
+		{"format in json", "waybar", `{{.Status}}`, passing,
+			`{"text":"success","tooltip":"3 of 3 repos passing","class":"success","alt":"success"}` + "\n"},
+	}
+	for _, tt := range tests {
+		tmpl, err := statuslineTemplate(tt.preset, tt.format)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var out strings.Builder
+		if err := writeStatusline(&out, tt.preset, tmpl, tt.summary); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, out.String(), tt.want)
+		}
+	}
+
+	if _, err := statuslineTemplate("polybar", ""); err == nil {
+		t.Error("statuslineTemplate(polybar) succeeded, want an error")
+	}
+	if _, err := statuslineTemplate("plain", "{{.Failing"); err == nil {
+		t.Error("statuslineTemplate with a bad format succeeded, want an error")
+	}
+}
@@ -0,0 +1,341 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/notify"
+	"github.com/thesimpledev/ghflow/internal/repo"
+)
+
+// ExitCancelled is watch's exit code for a run that was cancelled or
+// skipped rather than failed.
+const ExitCancelled = 3
+
+// Watch follows the runs for the checked-out commit until they finish and
+// exits with their conclusion: 0 success, 1 failure, 3 cancelled.
+func Watch(args []string) int {
+	fs := newFlagSet("watch", "watch [--workflow name] [--interval 5s] [--timeout 2m] [--notify] [path]")
+	workflow := fs.String("workflow", "", "only watch runs of the `workflow` with this name")
+	interval := fs.Duration("interval", 5*time.Second, "how often to poll")
+	startTimeout := fs.Duration("timeout", 2*time.Minute, "give up if no run shows up for HEAD within this long")
+	notifyDone := fs.Bool("notify", false, "send a desktop notification when done")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if *interval < time.Second {
+		*interval = time.Second
+	}
+	path := "."
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+
+	cfg, err := setup()
+	if err != nil {
+		return errorf("%v", err)
+	}
+	top, err := repo.TopLevel(path)
+	if err != nil {
+		return errorf("%v", err)
+	}
+	info, err := repo.GetRepoInfo(top)
+	if err != nil || info == nil {
+		return errorf("%s has no GitHub origin remote", top)
+	}
+	sha, err := repo.HeadSHA(top)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	var notifier notify.Notifier
+	if *notifyDone {
+		method := defaultNotifyMethod()
+		if cfg.Notify != nil && cfg.Notify.Method != "" {
+			method = cfg.Notify.Method
+		}
+		if notifier, err = notify.New(method); err != nil {
+			return errorf("%v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w := &watcher{
+		owner:    info.Owner,
+		name:     info.Name,
+		sha:      sha,
+		workflow: *workflow,
+		out:      os.Stdout,
+		tty:      isTerminal(os.Stdout),
+	}
+	fmt.Fprintf(w.out, "%s @ %s\n", info.Owner+"/"+info.Name, sha[:min(len(sha), 7)])
+
+	runs, err := w.waitForRuns(ctx, *interval, *startTimeout)
+	if err != nil {
+		return errorf("%v", err)
+	}
+	runs, err = w.follow(ctx, runs, *interval)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	code := conclusionCode(runs)
+	if notifier != nil {
+		_ = notifier.Notify(ctx, watchEvent(info.Owner+"/"+info.Name, runs, code))
+	}
+	return code
+}
+
+type watcher struct {
+	owner, name string
+	sha         string
+	workflow    string
+
+	out io.Writer
+	tty bool
+
+	drawn    int               // Lines drawn last time, to redraw in place
+	seen     map[string]string // Job/run -> last state printed, without a tty
+	fetchErr error             // Last refetch failed; shown until one succeeds
+}
+
+func (w *watcher) fetchRuns(ctx context.Context) ([]github.WorkflowRun, error) {
+	runs, err := github.FetchRuns(ctx, w.owner, w.name, github.RunFilter{HeadSHA: w.sha}, 50)
+	if err != nil {
+		return nil, err
+	}
+	if w.workflow == "" {
+		return runs, nil
+	}
+	var matched []github.WorkflowRun
+	for _, run := range runs {
+		if strings.EqualFold(run.WorkflowName, w.workflow) {
+			matched = append(matched, run)
+		}
+	}
+	return matched, nil
+}
+
+// waitForRuns polls until at least one run exists for the commit. Runs
+// take a few seconds to show up after a push.
+func (w *watcher) waitForRuns(ctx context.Context, interval, timeout time.Duration) ([]github.WorkflowRun, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		runs, err := w.fetchRuns(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case err != nil && time.Now().After(deadline):
+			return nil, err
+		case err != nil:
+			w.retrying(err)
+		case len(runs) > 0:
+			w.fetchErr = nil
+			return runs, nil
+		case time.Now().After(deadline):
+			return nil, fmt.Errorf("no workflow run for %s after %s", w.sha, timeout)
+		case w.tty:
+			fmt.Fprint(w.out, "\r\x1b[Kwaiting for a run to start...")
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// follow redraws the runs and their jobs until every run is completed.
+func (w *watcher) follow(ctx context.Context, runs []github.WorkflowRun, interval time.Duration) ([]github.WorkflowRun, error) {
+	if w.tty {
+		fmt.Fprint(w.out, "\r\x1b[K")
+	}
+	for {
+		jobs := make([][]github.Job, len(runs))
+		for i, run := range runs {
+			// A failed jobs fetch just shows the run without its jobs
+			jobs[i], _ = github.FetchRunJobs(ctx, w.owner, w.name, run.ID)
+		}
+		w.draw(runs, jobs)
+
+		done := true
+		for _, run := range runs {
+			if run.Status != "completed" {
+				done = false
+			}
+		}
+		if done {
+			return runs, nil
+		}
+
+		if err := sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+		// A failed refetch keeps the runs we have; only an interrupt
+		// stops the watch
+		latest, err := w.fetchRuns(ctx)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			w.retrying(err)
+			continue
+		}
+		w.fetchErr = nil
+		// The runs were seen already, so an empty list is a blip (e.g. a
+		// stale read); keep following what we have
+		if len(latest) > 0 {
+			runs = latest
+		}
+	}
+}
+
+// retrying reports a failed fetch that will be tried again.
+func (w *watcher) retrying(err error) {
+	if w.tty {
+		// follow shows it with the runs; until then there's only the
+		// waiting line
+		if w.drawn == 0 {
+			fmt.Fprintf(w.out, "\r\x1b[K%s", retryLine(err))
+		}
+	} else if w.fetchErr == nil || w.fetchErr.Error() != err.Error() {
+		fmt.Fprintln(w.out, retryLine(err))
+	}
+	w.fetchErr = err
+}
+
+func retryLine(err error) string {
+	return strings.TrimSpace(err.Error()) + "; retrying"
+}
+
+func (w *watcher) draw(runs []github.WorkflowRun, jobs [][]github.Job) {
+	now := time.Now()
+	var lines []string
+	for i, run := range runs {
+		lines = append(lines, fmt.Sprintf("%s %s #%d  %s", run.RunStatus().Icon(), run.WorkflowName, run.RunNumber,
+			elapsed(runStart(run), run.UpdatedAt, run.Status == "completed", now)))
+		for _, job := range jobs[i] {
+			lines = append(lines, fmt.Sprintf("    %s %s  %s", job.JobStatus().Icon(), job.Name,
+				elapsed(job.StartedAt, job.CompletedAt, job.Status == "completed", now)))
+		}
+	}
+
+	if w.tty {
+		if w.fetchErr != nil {
+			lines = append(lines, retryLine(w.fetchErr))
+		}
+		if w.drawn > 0 {
+			fmt.Fprintf(w.out, "\x1b[%dA\x1b[J", w.drawn)
+		}
+		for _, line := range lines {
+			fmt.Fprintln(w.out, line)
+		}
+		w.drawn = len(lines)
+		return
+	}
+
+	// Not a terminal: log state changes only
+	if w.seen == nil {
+		w.seen = map[string]string{}
+	}
+	for i, run := range runs {
+		w.logChange(fmt.Sprintf("run:%d", run.ID), run.Status+run.Conclusion,
+			fmt.Sprintf("%s %s #%d", run.RunStatus().Icon(), run.WorkflowName, run.RunNumber))
+		for _, job := range jobs[i] {
+			w.logChange(fmt.Sprintf("job:%d", job.ID), job.Status+job.Conclusion,
+				fmt.Sprintf("    %s %s (%s)", job.JobStatus().Icon(), job.Name, run.WorkflowName))
+		}
+	}
+}
+
+func (w *watcher) logChange(key, state, line string) {
+	if w.seen[key] == state {
+		return
+	}
+	w.seen[key] = state
+	fmt.Fprintln(w.out, line)
+}
+
+func runStart(run github.WorkflowRun) time.Time {
+	if !run.RunStartedAt.IsZero() {
+		return run.RunStartedAt
+	}
+	return run.CreatedAt
+}
+
+// elapsed is how long something has been running, or ran.
+func elapsed(start, end time.Time, completed bool, now time.Time) string {
+	if start.IsZero() {
+		return ""
+	}
+	if !completed || end.IsZero() {
+		end = now
+	}
+	return end.Sub(start).Round(time.Second).String()
+}
+
+// conclusionCode maps the runs' conclusions to an exit code: any failure
+// wins over a cancellation, which wins over success.
+func conclusionCode(runs []github.WorkflowRun) int {
+	code := ExitOK
+	for _, run := range runs {
+		switch run.Conclusion {
+		case "success", "neutral":
+		case "cancelled", "skipped", "stale":
+			if code == ExitOK {
+				code = ExitCancelled
+			}
+		default:
+			code = ExitFailure
+		}
+	}
+	return code
+}
+
+func watchEvent(repoName string, runs []github.WorkflowRun, code int) notify.Event {
+	run := runs[0]
+	for _, r := range runs {
+		if r.Conclusion != "success" {
+			run = r
+			break
+		}
+	}
+	e := notify.Event{Repo: repoName, Run: run, Transition: notify.Succeeded}
+	if code != ExitOK {
+		e.Transition = notify.Failed
+	}
+	return e
+}
+
+// defaultNotifyMethod is a desktop notification where one is likely to
+// work without configuration.
+func defaultNotifyMethod() string {
+	if runtime.GOOS == "linux" {
+		if _, err := exec.LookPath("notify-send"); err == nil {
+			return "notify-send"
+		}
+	}
+	return "osc9"
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/web"
+)
+
+// minWebInterval keeps a wall monitor from eating the API quota.
+const minWebInterval = 10 * time.Second
+
+// Web serves a read-only dashboard of the repos until interrupted.
+func Web(args []string) int {
+	fs := newFlagSet("web", "web [--listen localhost:8080] [--interval 30s] [--profile name] [--repo owner/name]")
+	listen := fs.String("listen", "localhost:8080", "`address` to serve the dashboard on; it has no authentication, so only widen this on a trusted network")
+	interval := fs.Duration("interval", 30*time.Second, "how often to poll the repos")
+	profile := fs.String("profile", "", "use the repos of a saved `profile` instead of the current ones")
+	var repos stringList
+	fs.Var(&repos, "repo", "only show `owner/name` (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if *interval < minWebInterval {
+		*interval = minWebInterval
+	}
+
+	cfg, err := setup()
+	if err != nil {
+		return errorf("%v", err)
+	}
+	selected, err := selectRepos(cfg, *profile, repos)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	title := "ghflow"
+	switch {
+	case *profile != "":
+		title += " - " + *profile
+	case cfg.ProfileName != "":
+		title += " - " + cfg.ProfileName
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := web.NewServer(title, selected)
+	if err := serve(ctx, *listen, srv); err != nil {
+		return errorf("%v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Serving the dashboard for %d repos on %s\n", len(selected), *listen)
+	if !loopback(*listen) {
+		fmt.Fprintln(os.Stderr, "Warning: the dashboard has no authentication and is reachable from other hosts")
+	}
+	srv.Run(ctx, *interval)
+	return ExitOK
+}
+
+// loopback reports whether addr only accepts local connections.
+func loopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -6,19 +6,126 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 )
 
 const appName = "ghflow"
 
 type Repo struct {
-	Path  string `json:"path"`
+	Path  string `json:"path,omitempty"` // Local checkout; empty for repos added by owner/name
 	Owner string `json:"owner"`
 	Name  string `json:"name"`
+
+	// Optional filter: only show runs on Branch and/or of Workflow
+	Branch   string `json:"branch,omitempty"`
+	Workflow string `json:"workflow,omitempty"`
+}
+
+// FullName returns the repo's "owner/name", which identifies it across
+// config reloads and grid rebuilds.
+func (r Repo) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+// MatchesRun reports whether a run on branch of workflow passes the repo's
+// filter. Workflow names match case-insensitively.
+func (r Repo) MatchesRun(branch, workflow string) bool {
+	if r.Branch != "" && branch != r.Branch {
+		return false
+	}
+	if r.Workflow != "" && !strings.EqualFold(workflow, r.Workflow) {
+		return false
+	}
+	return true
 }
 
 type Config struct {
 	Repos       []Repo `json:"repos"`
 	ProfileName string `json:"profile_name,omitempty"`
+
+	// RequestTimeout is the per-request API timeout in seconds.
+	// Zero uses the default.
+	RequestTimeout int `json:"request_timeout,omitempty"`
+
+	// PollMinInterval and PollMaxInterval bound how often each card polls,
+	// in seconds. Zero uses the defaults.
+	PollMinInterval int `json:"poll_min_interval,omitempty"`
+	PollMaxInterval int `json:"poll_max_interval,omitempty"`
+
+	// WebhookListen, when set, starts a receiver for workflow_run and
+	// workflow_job webhooks signed with WebhookSecret.
+	WebhookListen string `json:"webhook_listen,omitempty"`
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+
+	// StatsRuns and StatsDays bound the runs the stats view looks at:
+	// the last StatsRuns default-branch runs, created within StatsDays
+	// days when set. Zero uses the defaults (100 runs, any age).
+	StatsRuns int `json:"stats_runs,omitempty"`
+	StatsDays int `json:"stats_days,omitempty"`
+
+	// InboxDays is how far back the failure inbox looks, in days.
+	// Zero uses the default (7 days).
+	InboxDays int `json:"inbox_days,omitempty"`
+
+	// Notify, when set, sends a notification when a run changes state.
+	Notify *NotifyConfig `json:"notify,omitempty"`
+
+	// Hooks runs local commands on run events.
+	Hooks *HooksConfig `json:"hooks,omitempty"`
+}
+
+// HooksConfig maps run events to shell commands.
+type HooksConfig struct {
+	// On maps an event (run_started, run_succeeded, run_failed,
+	// job_failed, queue_timeout) to the commands it runs.
+	On map[string][]string `json:"on"`
+
+	// Timeout is how long a command may run, in seconds. Zero uses the
+	// default (30s).
+	Timeout int `json:"timeout,omitempty"`
+
+	// QueueTimeout is how long a run may sit queued before queue_timeout
+	// fires, in seconds. Zero uses the default (10 minutes).
+	QueueTimeout int `json:"queue_timeout,omitempty"`
+}
+
+// NotifyConfig picks how and when ghflow notifies about run transitions.
+type NotifyConfig struct {
+	// Method is "bell", "osc9", "osc777" or "notify-send". Empty sends
+	// nothing locally, e.g. when only webhooks are wanted.
+	Method string `json:"method,omitempty"`
+
+	// Rules choose which transitions notify. Without rules, failures and
+	// fixes on every repo do.
+	Rules []NotifyRule `json:"rules,omitempty"`
+
+	// SkipOwnRuns drops local notifications for runs the gh user
+	// triggered. Webhooks still post them.
+	SkipOwnRuns bool `json:"skip_own_runs,omitempty"`
+
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
+}
+
+// WebhookConfig posts transitions to a URL, such as a Slack or Discord
+// incoming webhook.
+type WebhookConfig struct {
+	URL string `json:"url"`
+
+	// Preset is "slack", "discord" or "json" (the default). Template, a Go
+	// text/template producing JSON, replaces the preset when set.
+	Preset   string `json:"preset,omitempty"`
+	Template string `json:"template,omitempty"`
+
+	// Rules work like NotifyConfig.Rules, for this webhook only.
+	Rules []NotifyRule `json:"rules,omitempty"`
+}
+
+// NotifyRule matches transitions on a repo and branch. Empty fields match
+// everything.
+type NotifyRule struct {
+	Repo        string   `json:"repo,omitempty"` // owner/name
+	Branch      string   `json:"branch,omitempty"`
+	Transitions []string `json:"transitions,omitempty"` // failed, fixed, succeeded, started
 }
 
 func configDir() (string, error) {
@@ -33,6 +140,43 @@ func configDir() (string, error) {
 	return filepath.Join(configHome, appName), nil
 }
 
+// StateDir is where ghflow keeps data it collects, as opposed to settings:
+// $XDG_STATE_HOME/ghflow, or ~/.local/state/ghflow.
+func StateDir() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, appName), nil
+}
+
+// RuntimeDir is where ghflow puts its sockets: $XDG_RUNTIME_DIR/ghflow,
+// or the state directory.
+func RuntimeDir() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, appName), nil
+	}
+	return StateDir()
+}
+
+// RepoFile maps repo ("owner/name") to dir/owner/name+ext, refusing
+// anything that could point outside dir.
+func RepoFile(dir, repo, ext string) (string, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || !validPathPart(owner) || !validPathPart(name) {
+		return "", fmt.Errorf("invalid repo %q", repo)
+	}
+	return filepath.Join(dir, owner, name+ext), nil
+}
+
+func validPathPart(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`)
+}
+
 func configPath() (string, error) {
 	dir, err := configDir()
 	if err != nil {
@@ -95,6 +239,19 @@ func (c *Config) AddRepo(repo Repo) {
 	c.Repos = append(c.Repos, repo)
 }
 
+// SetFilter sets the branch and workflow filter for a repo. Empty values
+// clear that part of the filter. It reports whether the repo was found.
+func (c *Config) SetFilter(owner, name, branch, workflow string) bool {
+	for i, r := range c.Repos {
+		if r.Owner == owner && r.Name == name {
+			c.Repos[i].Branch = branch
+			c.Repos[i].Workflow = workflow
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) RemoveRepo(owner, name string) {
 	for i, r := range c.Repos {
 		if r.Owner == owner && r.Name == name {
@@ -199,3 +356,33 @@ func ListProfiles() ([]string, error) {
 
 	return profiles, nil
 }
+
+// AllRepos returns the repos in c followed by those of every saved
+// profile, each listed once. Profiles that fail to load are skipped.
+func (c *Config) AllRepos() ([]Repo, error) {
+	seen := map[string]bool{}
+	var repos []Repo
+	add := func(list []Repo) {
+		for _, r := range list {
+			if seen[r.FullName()] {
+				continue
+			}
+			seen[r.FullName()] = true
+			repos = append(repos, r)
+		}
+	}
+	add(c.Repos)
+
+	profiles, err := ListProfiles()
+	if err != nil {
+		return repos, err
+	}
+	for _, name := range profiles {
+		profile, err := LoadProfile(name)
+		if err != nil {
+			continue
+		}
+		add(profile.Repos)
+	}
+	return repos, nil
+}
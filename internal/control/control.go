@@ -0,0 +1,106 @@
+// Package control lets scripts and editor plugins drive a running
+// dashboard over JSON-RPC on a Unix socket: run its slash commands and
+// ask what it shows.
+package control
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+// Methods a dashboard answers.
+const (
+	MethodCommand = "command" // {command} runs a slash command, e.g. "/focus owner/name"
+	MethodStatus  = "status"  // -> []CardStatus, in grid order
+	MethodRun     = "run"     // {repo, run_id} -> RunStatus
+)
+
+type CommandParams struct {
+	Command string `json:"command"`
+}
+
+type RunParams struct {
+	Repo  string `json:"repo,omitempty"` // owner/name; any card if empty
+	RunID int64  `json:"run_id"`
+}
+
+// CardStatus is what a card shows.
+type CardStatus struct {
+	Repo      string              `json:"repo"`
+	Branch    string              `json:"branch,omitempty"`
+	Workflow  string              `json:"workflow,omitempty"`
+	Path      string              `json:"path,omitempty"`
+	Status    github.RunStatus    `json:"status"`
+	Latest    *github.WorkflowRun `json:"latest,omitempty"`
+	Error     string              `json:"error,omitempty"`
+	FetchedAt time.Time           `json:"fetched_at,omitzero"`
+	Stale     bool                `json:"stale,omitempty"`
+	Selected  bool                `json:"selected,omitempty"`
+}
+
+// RunStatus is a run on the dashboard, with its jobs.
+type RunStatus struct {
+	Repo string             `json:"repo"`
+	Run  github.WorkflowRun `json:"run"`
+	Jobs []github.Job       `json:"jobs"`
+}
+
+const socketPrefix = "ui-"
+
+// SocketPath is where the dashboard with process ID pid listens. Each
+// dashboard gets its own socket, so several can run at once.
+func SocketPath(pid int) (string, error) {
+	dir, err := config.RuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s%d.sock", socketPrefix, pid)), nil
+}
+
+// Sockets lists the dashboards' sockets, most recently started first.
+// Some may be left over from dashboards that died.
+func Sockets() ([]string, error) {
+	dir, err := config.RuntimeDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	type socket struct {
+		path    string
+		started time.Time
+	}
+	var sockets []socket
+	for _, e := range entries {
+		pid, ok := strings.CutPrefix(e.Name(), socketPrefix)
+		if !ok || e.Type()&os.ModeSocket == 0 {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimSuffix(pid, ".sock")); err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		sockets = append(sockets, socket{filepath.Join(dir, e.Name()), info.ModTime()})
+	}
+	sort.Slice(sockets, func(i, j int) bool {
+		return sockets[i].started.After(sockets[j].started)
+	})
+	paths := make([]string, len(sockets))
+	for i, s := range sockets {
+		paths[i] = s.path
+	}
+	return paths, nil
+}
@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/rpc"
+)
+
+// Client talks to a running daemon.
+type Client struct {
+	rpc     *rpc.Client
+	updates chan []RepoState
+}
+
+// Dial connects to the daemon at SocketPath. It fails quickly when no
+// daemon is running.
+func Dial() (*Client, error) {
+	path, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+	rc, err := rpc.Dial(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{rpc: rc, updates: make(chan []RepoState, 1)}
+	go c.readUpdates()
+	return c, nil
+}
+
+func (c *Client) readUpdates() {
+	defer close(c.updates)
+	for note := range c.rpc.Notifications() {
+		if note.Method != NotifyUpdate {
+			continue
+		}
+		var params updateParams
+		if err := json.Unmarshal(note.Params, &params); err != nil {
+			continue
+		}
+		c.push(params.States)
+	}
+}
+
+// push delivers states, replacing an update nobody has read yet.
+func (c *Client) push(states []RepoState) {
+	select {
+	case <-c.updates:
+	default:
+	}
+	select {
+	case c.updates <- states:
+	default:
+	}
+}
+
+// Updates delivers every repo's state after each poll, once Watch has
+// been called. It's closed when the daemon goes away.
+func (c *Client) Updates() <-chan []RepoState {
+	return c.updates
+}
+
+// Watch subscribes to updates, making sure the daemon polls repos. What
+// the daemon already knows about them is delivered on Updates straight
+// away.
+func (c *Client) Watch(ctx context.Context, repos []config.Repo) error {
+	var states []RepoState
+	if err := c.rpc.Call(ctx, MethodSubscribe, reposParams{Repos: repos}, &states); err != nil {
+		return err
+	}
+	if len(states) > 0 {
+		c.push(states)
+	}
+	return nil
+}
+
+// State returns the daemon's state of repos it knows about.
+func (c *Client) State(ctx context.Context, repos []config.Repo) ([]RepoState, error) {
+	var states []RepoState
+	err := c.rpc.Call(ctx, MethodState, reposParams{Repos: repos}, &states)
+	return states, err
+}
+
+// Refresh asks the daemon to poll now.
+func (c *Client) Refresh(ctx context.Context) error {
+	return c.rpc.Call(ctx, MethodRefresh, nil, nil)
+}
+
+// Rerun re-runs a run, or only its failed jobs.
+func (c *Client) Rerun(ctx context.Context, repo string, runID int64, failedOnly bool) error {
+	return c.rpc.Call(ctx, MethodRerun, rerunParams{Repo: repo, RunID: runID, FailedOnly: failedOnly}, nil)
+}
+
+func (c *Client) Close() error {
+	return c.rpc.Close()
+}
@@ -0,0 +1,380 @@
+// Package daemon polls repos on behalf of every ghflow running as the
+// same user, and serves their state over a Unix socket with JSON-RPC.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/cache"
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/history"
+	"github.com/thesimpledev/ghflow/internal/hooks"
+	"github.com/thesimpledev/ghflow/internal/notify"
+	"github.com/thesimpledev/ghflow/internal/poll"
+	"github.com/thesimpledev/ghflow/internal/rpc"
+)
+
+// Methods the daemon answers, and the notification subscribers get after
+// every poll.
+const (
+	MethodSubscribe = "subscribe" // Watch repos and get updates: {repos} -> []RepoState
+	MethodState     = "state"     // {repos} -> []RepoState, every repo if none given
+	MethodRefresh   = "refresh"   // Poll now
+	MethodRerun     = "rerun"     // {repo, run_id, failed_only}
+
+	NotifyUpdate = "update" // {states}
+)
+
+const (
+	DefaultInterval       = time.Minute
+	DefaultActiveInterval = 10 * time.Second
+)
+
+// RepoState is the latest known runs of a repo. Runs stay from the last
+// successful poll when a later one fails.
+type RepoState struct {
+	Repo      config.Repo          `json:"repo"`
+	Runs      []github.WorkflowRun `json:"runs"`
+	Error     string               `json:"error,omitempty"`
+	FetchedAt time.Time            `json:"fetched_at,omitzero"` // Zero until a poll succeeds
+}
+
+// Result converts s to what poll.FetchAll would have returned.
+func (s RepoState) Result() poll.Result {
+	r := poll.Result{Repo: s.Repo, Runs: s.Runs}
+	if s.Error != "" {
+		r.Err = errors.New(s.Error)
+	}
+	return r
+}
+
+// Key identifies a repo together with its filters, which change what's
+// fetched.
+func Key(repo config.Repo) string {
+	return strings.ToLower(repo.FullName()) + "?branch=" + repo.Branch + "&workflow=" + repo.Workflow
+}
+
+type reposParams struct {
+	Repos []config.Repo `json:"repos,omitempty"`
+}
+
+type rerunParams struct {
+	Repo       string `json:"repo"` // owner/name
+	RunID      int64  `json:"run_id"`
+	FailedOnly bool   `json:"failed_only,omitempty"`
+}
+
+type updateParams struct {
+	States []RepoState `json:"states"`
+}
+
+// SocketPath is where the daemon listens, in the runtime directory.
+func SocketPath() (string, error) {
+	dir, err := config.RuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon.sock"), nil
+}
+
+// Daemon polls the configured repos, plus any its clients watch, and
+// owns caching, history, notifications and hooks while it runs.
+type Daemon struct {
+	server   *rpc.Server
+	cache    *cache.Cache
+	history  *history.Store
+	notifier *notify.Dispatcher
+	hooks    *hooks.Runner
+
+	interval       time.Duration
+	activeInterval time.Duration
+
+	refresh chan struct{}
+
+	mu      sync.Mutex
+	states  map[string]RepoState
+	watched map[*rpc.Conn][]config.Repo
+}
+
+// New sets up a daemon for cfg. Cache and history are best effort, as
+// in the dashboard; bad notify or hooks settings are errors.
+func New(cfg *config.Config) (*Daemon, error) {
+	d := &Daemon{
+		server:         rpc.NewServer(),
+		interval:       DefaultInterval,
+		activeInterval: DefaultActiveInterval,
+		refresh:        make(chan struct{}, 1),
+		states:         map[string]RepoState{},
+		watched:        map[*rpc.Conn][]config.Repo{},
+	}
+	if cfg.PollMinInterval > 0 {
+		d.activeInterval = time.Duration(cfg.PollMinInterval) * time.Second
+	}
+	d.cache, _ = cache.Open()
+	d.history, _ = history.Open()
+
+	var err error
+	if cfg.Notify != nil {
+		if d.notifier, err = notify.NewDispatcher(*cfg.Notify); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Hooks != nil {
+		if d.hooks, err = hooks.NewRunner(*cfg.Hooks); err != nil {
+			return nil, err
+		}
+	}
+
+	d.server.Handle(MethodSubscribe, d.subscribe)
+	d.server.Handle(MethodState, d.state)
+	d.server.Handle(MethodRefresh, func(context.Context, *rpc.Conn, json.RawMessage) (any, error) {
+		d.Refresh()
+		return struct{}{}, nil
+	})
+	d.server.Handle(MethodRerun, d.rerun)
+	d.server.OnDisconnect(func(conn *rpc.Conn) {
+		d.mu.Lock()
+		delete(d.watched, conn)
+		d.mu.Unlock()
+	})
+	return d, nil
+}
+
+// SetIntervals sets how often to poll when nothing is running and when
+// something is. Zero keeps the default.
+func (d *Daemon) SetIntervals(idle, active time.Duration) {
+	if idle > 0 {
+		d.interval = idle
+	}
+	if active > 0 {
+		d.activeInterval = active
+	}
+}
+
+// Refresh polls now rather than at the next interval.
+func (d *Daemon) Refresh() {
+	select {
+	case d.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Run serves clients on ln and polls until ctx is done.
+func (d *Daemon) Run(ctx context.Context, ln net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- d.server.Serve(ctx, ln)
+	}()
+
+	for {
+		wait := d.interval
+		if d.poll(ctx) {
+			wait = d.activeInterval
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case err := <-serveErr:
+			timer.Stop()
+			return err
+		case <-d.refresh:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// repos is everything to poll: the current config, re-read each time so
+// repos added in a dashboard are picked up, and what clients watch.
+func (d *Daemon) repos() []config.Repo {
+	var repos []config.Repo
+	if cfg, err := config.Load(); err == nil {
+		repos = append(repos, cfg.Repos...)
+	}
+	d.mu.Lock()
+	for _, watched := range d.watched {
+		repos = append(repos, watched...)
+	}
+	d.mu.Unlock()
+
+	seen := map[string]bool{}
+	unique := repos[:0]
+	for _, r := range repos {
+		if k := Key(r); !seen[k] {
+			seen[k] = true
+			unique = append(unique, r)
+		}
+	}
+	return unique
+}
+
+// poll fetches every repo and tells subscribers, then records the jobs
+// of failed and re-run runs. It reports whether any run is queued or in
+// progress.
+func (d *Daemon) poll(ctx context.Context) (active bool) {
+	repos := d.repos()
+	results := poll.FetchAll(ctx, repos)
+	if ctx.Err() != nil {
+		return false
+	}
+	now := time.Now()
+
+	d.mu.Lock()
+	states := make(map[string]RepoState, len(results))
+	for _, r := range results {
+		k := Key(r.Repo)
+		prev := d.states[k]
+		state := RepoState{Repo: r.Repo, Runs: prev.Runs, FetchedAt: prev.FetchedAt}
+		if r.Err != nil {
+			state.Error = r.Err.Error()
+		} else {
+			// Only compare against what this daemon fetched itself
+			if !prev.FetchedAt.IsZero() {
+				d.transitions(ctx, r.Repo, notify.Detect(r.Repo.FullName(), prev.Runs, r.Runs))
+			}
+			if d.hooks != nil {
+				d.hooks.Queued(ctx, r.Repo, r.Runs, now)
+			}
+			state.Runs = r.Runs
+			state.FetchedAt = now
+			d.save(r.Repo, r.Runs, now)
+		}
+		for _, run := range state.Runs {
+			if run.Status != "completed" {
+				active = true
+				break
+			}
+		}
+		states[k] = state
+	}
+	d.states = states
+	update := updateParams{States: d.sortedLocked()}
+	d.mu.Unlock()
+
+	d.server.Broadcast(NotifyUpdate, update)
+
+	if d.history != nil {
+		for _, r := range results {
+			if r.Err == nil {
+				_ = poll.RecordJobs(ctx, d.history, r.Repo, r.Runs)
+			}
+		}
+	}
+	return active
+}
+
+// transitions runs hooks, which start their commands in the background,
+// and sends notifications without holding up the poll.
+func (d *Daemon) transitions(ctx context.Context, repo config.Repo, events []notify.Event) {
+	if len(events) == 0 {
+		return
+	}
+	if d.hooks != nil {
+		d.hooks.Transitions(ctx, repo, events)
+	}
+	if d.notifier != nil {
+		go func() {
+			_ = d.notifier.Send(ctx, events)
+		}()
+	}
+}
+
+// save writes runs to the cache and history, so the dashboard and the
+// headless commands see them even without the daemon.
+func (d *Daemon) save(repo config.Repo, runs []github.WorkflowRun, fetchedAt time.Time) {
+	if d.cache != nil {
+		_ = d.cache.Save(repo, runs, fetchedAt)
+	}
+	if d.history != nil {
+		_ = d.history.RecordRuns(repo.FullName(), runs)
+	}
+}
+
+// sortedLocked returns the states in a stable order.
+func (d *Daemon) sortedLocked() []RepoState {
+	keys := make([]string, 0, len(d.states))
+	for k := range d.states {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	states := make([]RepoState, len(keys))
+	for i, k := range keys {
+		states[i] = d.states[k]
+	}
+	return states
+}
+
+func (d *Daemon) subscribe(_ context.Context, conn *rpc.Conn, raw json.RawMessage) (any, error) {
+	var params reposParams
+	if err := rpc.DecodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	conn.Subscribe()
+
+	d.mu.Lock()
+	d.watched[conn] = params.Repos
+	states, missing := d.lookupLocked(params.Repos)
+	d.mu.Unlock()
+	if missing {
+		d.Refresh()
+	}
+	return states, nil
+}
+
+func (d *Daemon) state(_ context.Context, _ *rpc.Conn, raw json.RawMessage) (any, error) {
+	var params reposParams
+	if err := rpc.DecodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(params.Repos) == 0 {
+		return d.sortedLocked(), nil
+	}
+	states, _ := d.lookupLocked(params.Repos)
+	return states, nil
+}
+
+// lookupLocked returns the states of repos that have one, and whether
+// any didn't.
+func (d *Daemon) lookupLocked(repos []config.Repo) ([]RepoState, bool) {
+	states := []RepoState{}
+	missing := false
+	for _, r := range repos {
+		if s, ok := d.states[Key(r)]; ok {
+			states = append(states, s)
+		} else {
+			missing = true
+		}
+	}
+	return states, missing
+}
+
+func (d *Daemon) rerun(ctx context.Context, _ *rpc.Conn, raw json.RawMessage) (any, error) {
+	var params rerunParams
+	if err := rpc.DecodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	owner, name, ok := strings.Cut(params.Repo, "/")
+	if !ok || params.RunID == 0 {
+		return nil, rpc.Errorf(rpc.CodeInvalidParams, "rerun needs repo (owner/name) and run_id")
+	}
+	if err := github.RerunRun(ctx, owner, name, params.RunID, params.FailedOnly); err != nil {
+		return nil, err
+	}
+	d.Refresh()
+	return struct{}{}, nil
+}
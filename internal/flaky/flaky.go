@@ -0,0 +1,111 @@
+package flaky
+
+import (
+	"sort"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/history"
+)
+
+// Job is a job that both passed and failed on the same commit.
+type Job struct {
+	Workflow string
+	Name     string
+
+	// Occurrences counts commits where the job's outcome flipped, whether
+	// it failed then passed on re-run or alternated across runs.
+	Occurrences int
+	LastSeen    time.Time
+}
+
+// Key identifies a job across runs and attempts.
+func Key(workflow, name string) string {
+	return workflow + "\x00" + name
+}
+
+type outcomes struct {
+	passed, failed bool
+	last           time.Time
+}
+
+// Detect finds flaky jobs in a repo's recorded history. Every attempt of
+// a run records its own jobs, so a failure on attempt 1 followed by a
+// pass on attempt 2 shows up as two outcomes for the same commit.
+func Detect(records []history.RunRecord) []Job {
+	// workflow/job -> commit -> what we saw
+	seen := map[string]map[string]*outcomes{}
+	names := map[string]Job{}
+
+	for _, rec := range records {
+		for _, jr := range rec.Jobs {
+			job := jr.Job
+			if job.Status != "completed" {
+				continue
+			}
+			sha := job.HeadSHA
+			if sha == "" {
+				sha = rec.Run.HeadSHA
+			}
+			workflow := job.WorkflowName
+			if workflow == "" {
+				workflow = rec.Run.WorkflowName
+			}
+			if sha == "" {
+				continue
+			}
+
+			key := Key(workflow, job.Name)
+			if _, ok := seen[key]; !ok {
+				seen[key] = map[string]*outcomes{}
+				names[key] = Job{Workflow: workflow, Name: job.Name}
+			}
+			o, ok := seen[key][sha]
+			if !ok {
+				o = &outcomes{}
+				seen[key][sha] = o
+			}
+			switch job.Conclusion {
+			case "success":
+				o.passed = true
+			case "failure", "timed_out":
+				o.failed = true
+			default:
+				continue
+			}
+			if job.CompletedAt.After(o.last) {
+				o.last = job.CompletedAt
+			}
+		}
+	}
+
+	var jobs []Job
+	for key, bySHA := range seen {
+		job := names[key]
+		for _, o := range bySHA {
+			if !o.passed || !o.failed {
+				continue
+			}
+			job.Occurrences++
+			if o.last.After(job.LastSeen) {
+				job.LastSeen = o.last
+			}
+		}
+		if job.Occurrences > 0 {
+			jobs = append(jobs, job)
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].Occurrences != jobs[j].Occurrences {
+			return jobs[i].Occurrences > jobs[j].Occurrences
+		}
+		if !jobs[i].LastSeen.Equal(jobs[j].LastSeen) {
+			return jobs[i].LastSeen.After(jobs[j].LastSeen)
+		}
+		if jobs[i].Workflow != jobs[j].Workflow {
+			return jobs[i].Workflow < jobs[j].Workflow
+		}
+		return jobs[i].Name < jobs[j].Name
+	})
+	return jobs
+}
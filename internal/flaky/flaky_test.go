@@ -0,0 +1,67 @@
+package flaky
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/history"
+)
+
+func TestDetect(t *testing.T) {
+	at := func(minute int) time.Time {
+		return time.Date(2026, 10, 18, 10, minute, 0, 0, time.UTC)
+	}
+	job := func(name string, attempt int, conclusion string, minute int) history.JobRecord {
+		return history.JobRecord{Job: github.Job{
+			Name:        name,
+			RunAttempt:  attempt,
+			Status:      "completed",
+			Conclusion:  conclusion,
+			CompletedAt: at(minute),
+		}}
+	}
+	record := func(id int64, sha string, jobs ...history.JobRecord) history.RunRecord {
+		return history.RunRecord{
+			Run:  github.WorkflowRun{ID: id, HeadSHA: sha, WorkflowName: "CI"},
+			Jobs: jobs,
+		}
+	}
+
+	records := []history.RunRecord{
+		// Failed on attempt 1, passed on re-run
+		record(1, "aaa",
+			job("test", 1, "failure", 1),
+			job("test", 2, "success", 5),
+			job("build", 1, "success", 1),
+		),
+		// Alternated across two runs of one commit
+		record(2, "bbb", job("lint", 1, "timed_out", 2)),
+		record(3, "bbb", job("lint", 1, "success", 5)),
+		// Failed on one commit and passed on the next: a fix, not a flake
+		record(4, "ccc", job("e2e", 1, "failure", 3)),
+		record(5, "ddd", job("e2e", 1, "success", 4)),
+		// No commit to compare on
+		record(6, "", job("deploy", 1, "failure", 3), job("deploy", 2, "success", 4)),
+		// Cancelled and in-progress jobs don't count as outcomes
+		record(7, "eee", job("docs", 1, "cancelled", 3), job("docs", 2, "success", 4)),
+		record(8, "fff", job("bench", 1, "failure", 3), history.JobRecord{Job: github.Job{Name: "bench", RunAttempt: 2, Status: "in_progress"}}),
+		// Flipped on two commits
+		record(9, "ggg", job("integration", 1, "failure", 1), job("integration", 2, "success", 2)),
+		record(10, "hhh", job("integration", 1, "success", 3), job("integration", 2, "failure", 4)),
+	}
+
+	want := []Job{
+		{Workflow: "CI", Name: "integration", Occurrences: 2, LastSeen: at(4)},
+		// Same count and last seen: by name
+		{Workflow: "CI", Name: "lint", Occurrences: 1, LastSeen: at(5)},
+		{Workflow: "CI", Name: "test", Occurrences: 1, LastSeen: at(5)},
+	}
+	// Detect walks maps, so run it enough times to catch unstable order.
+	for i := 0; i < 20; i++ {
+		if got := Detect(records); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Detect() = %+v, want %+v", got, want)
+		}
+	}
+}
@@ -1,13 +1,46 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os/exec"
 	"regexp"
 	"time"
 )
 
+// DefaultRequestTimeout bounds a single gh api call unless overridden
+// with SetRequestTimeout.
+const DefaultRequestTimeout = 30 * time.Second
+
+var requestTimeout = DefaultRequestTimeout
+
+// ErrOffline is returned by every API call while offline mode is on.
+var ErrOffline = errors.New("offline mode: network calls are disabled")
+
+var offline bool
+
+// SetOffline turns offline mode on or off. Set it before any fetch starts.
+func SetOffline(on bool) {
+	offline = on
+}
+
+// Offline reports whether offline mode is on.
+func Offline() bool {
+	return offline
+}
+
+// SetRequestTimeout sets the per-request timeout applied to every API
+// call. Non-positive values restore the default.
+func SetRequestTimeout(d time.Duration) {
+	if d <= 0 {
+		d = DefaultRequestTimeout
+	}
+	requestTimeout = d
+}
+
 // GitHub owner and repo names only contain letters, digits, hyphens,
 // underscores, and dots. Anything else could alter the API path we
 // build below (e.g. a "/" or "?" smuggled in via a git remote URL).
@@ -31,17 +64,55 @@ const (
 	StatusUnknown    RunStatus = "unknown"
 )
 
+// Plain-text status indicators, shared by the dashboard, watch and the
+// web dashboard.
+const (
+	IconSuccess   = "[ok]"
+	IconFailure   = "[X]"
+	IconRunning   = "[~]"
+	IconPending   = "[?]"
+	IconCancelled = "[-]"
+	IconUnknown   = "[.]"
+)
+
+// Icon is the plain-text indicator for s.
+func (s RunStatus) Icon() string {
+	switch s {
+	case StatusSuccess:
+		return IconSuccess
+	case StatusFailure:
+		return IconFailure
+	case StatusInProgress:
+		return IconRunning
+	case StatusPending:
+		return IconPending
+	case StatusCancelled:
+		return IconCancelled
+	default:
+		return IconUnknown
+	}
+}
+
 type WorkflowRun struct {
 	ID           int64     `json:"id"`
 	Name         string    `json:"name"`
 	HeadBranch   string    `json:"head_branch"`
+	HeadSHA      string    `json:"head_sha"`
 	Status       string    `json:"status"`
 	Conclusion   string    `json:"conclusion"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	HTMLURL      string    `json:"html_url"`
 	RunNumber    int       `json:"run_number"`
+	RunAttempt   int       `json:"run_attempt"`
+	RunStartedAt time.Time `json:"run_started_at"`
 	WorkflowName string    `json:"workflow_name"`
+	Actor        Actor     `json:"actor"`
+}
+
+// Actor is the user who triggered a run.
+type Actor struct {
+	Login string `json:"login"`
 }
 
 type workflowRunsResponse struct {
@@ -71,37 +142,122 @@ func (r *WorkflowRun) RunStatus() RunStatus {
 	return StatusUnknown
 }
 
-func FetchWorkflowRuns(owner, repo string, limit int) ([]WorkflowRun, error) {
+func FetchWorkflowRuns(ctx context.Context, owner, repo string, limit int) ([]WorkflowRun, error) {
+	return FetchRuns(ctx, owner, repo, RunFilter{}, limit)
+}
+
+// RunFilter narrows the runs returned by FetchRuns. Empty fields match
+// everything.
+type RunFilter struct {
+	Branch  string
+	HeadSHA string
+	Since   time.Time // Only runs created on or after this day
+}
+
+// maxPerPage is the largest page size the runs endpoint accepts.
+const maxPerPage = 100
+
+// FetchRuns returns up to limit runs matching filter, newest first,
+// paging through the API as needed.
+func FetchRuns(ctx context.Context, owner, repo string, filter RunFilter, limit int) ([]WorkflowRun, error) {
 	if err := checkOwnerRepo(owner, repo); err != nil {
 		return nil, err
 	}
-	endpoint := fmt.Sprintf("repos/%s/%s/actions/runs?per_page=%d", owner, repo, limit)
 
-	cmd := exec.Command("gh", "api", endpoint) // #nosec G204 -- fixed binary, owner/repo validated by checkOwnerRepo, no shell involved
-	output, err := cmd.Output()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("gh api failed: %s", string(exitErr.Stderr))
+	query := url.Values{}
+	if filter.Branch != "" {
+		query.Set("branch", filter.Branch)
+	}
+	if filter.HeadSHA != "" {
+		query.Set("head_sha", filter.HeadSHA)
+	}
+	if !filter.Since.IsZero() {
+		query.Set("created", ">="+filter.Since.Format("2006-01-02"))
+	}
+	query.Set("per_page", fmt.Sprint(min(limit, maxPerPage)))
+
+	var runs []WorkflowRun
+	for page := 1; len(runs) < limit; page++ {
+		query.Set("page", fmt.Sprint(page))
+		endpoint := fmt.Sprintf("repos/%s/%s/actions/runs?%s", owner, repo, query.Encode())
+
+		output, err := ghAPI(ctx, endpoint)
+		if err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("gh api failed: %w", err)
+
+		var response workflowRunsResponse
+		if err := json.Unmarshal(output, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		for i := range response.WorkflowRuns {
+			if response.WorkflowRuns[i].WorkflowName == "" {
+				response.WorkflowRuns[i].WorkflowName = response.WorkflowRuns[i].Name
+			}
+		}
+		runs = append(runs, response.WorkflowRuns...)
+
+		if len(response.WorkflowRuns) < min(limit, maxPerPage) || len(runs) >= response.TotalCount {
+			break
+		}
+	}
+
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+// LookupRepo checks that owner/repo exists and can be read, and returns
+// its name as GitHub spells it.
+func LookupRepo(ctx context.Context, owner, repo string) (string, string, error) {
+	if err := checkOwnerRepo(owner, repo); err != nil {
+		return "", "", err
 	}
 
-	var response workflowRunsResponse
+	output, err := ghAPI(ctx, fmt.Sprintf("repos/%s/%s", owner, repo))
+	if err != nil {
+		return "", "", fmt.Errorf("%s/%s: %w", owner, repo, err)
+	}
+
+	var response struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	}
 	if err := json.Unmarshal(output, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return "", "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if response.Owner.Login == "" || response.Name == "" {
+		return owner, repo, nil
 	}
+	return response.Owner.Login, response.Name, nil
+}
 
-	for i := range response.WorkflowRuns {
-		if response.WorkflowRuns[i].WorkflowName == "" {
-			response.WorkflowRuns[i].WorkflowName = response.WorkflowRuns[i].Name
-		}
+// FetchDefaultBranch returns the name of the repo's default branch.
+func FetchDefaultBranch(ctx context.Context, owner, repo string) (string, error) {
+	if err := checkOwnerRepo(owner, repo); err != nil {
+		return "", err
+	}
+
+	output, err := ghAPI(ctx, fmt.Sprintf("repos/%s/%s", owner, repo))
+	if err != nil {
+		return "", err
 	}
 
-	return response.WorkflowRuns, nil
+	var response struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := json.Unmarshal(output, &response); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	return response.DefaultBranch, nil
 }
 
-func GetLatestRunStatus(owner, repo string) (RunStatus, *WorkflowRun, error) {
-	runs, err := FetchWorkflowRuns(owner, repo, 1)
+func GetLatestRunStatus(ctx context.Context, owner, repo string) (RunStatus, *WorkflowRun, error) {
+	runs, err := FetchWorkflowRuns(ctx, owner, repo, 1)
 	if err != nil {
 		return StatusUnknown, nil, err
 	}
@@ -115,12 +271,16 @@ func GetLatestRunStatus(owner, repo string) (RunStatus, *WorkflowRun, error) {
 }
 
 type Job struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Status      string    `json:"status"`
-	Conclusion  string    `json:"conclusion"`
-	StartedAt   time.Time `json:"started_at"`
-	CompletedAt time.Time `json:"completed_at"`
+	ID           int64     `json:"id"`
+	RunID        int64     `json:"run_id"`
+	RunAttempt   int       `json:"run_attempt"`
+	HeadSHA      string    `json:"head_sha"`
+	WorkflowName string    `json:"workflow_name"`
+	Name         string    `json:"name"`
+	Status       string    `json:"status"`
+	Conclusion   string    `json:"conclusion"`
+	StartedAt    time.Time `json:"started_at"`
+	CompletedAt  time.Time `json:"completed_at"`
 }
 
 type jobsResponse struct {
@@ -157,19 +317,25 @@ func (j *Job) Duration() time.Duration {
 	return j.CompletedAt.Sub(j.StartedAt)
 }
 
-func FetchRunJobs(owner, repo string, runID int64) ([]Job, error) {
+func FetchRunJobs(ctx context.Context, owner, repo string, runID int64) ([]Job, error) {
 	if err := checkOwnerRepo(owner, repo); err != nil {
 		return nil, err
 	}
-	endpoint := fmt.Sprintf("repos/%s/%s/actions/runs/%d/jobs", owner, repo, runID)
+	return fetchJobs(ctx, fmt.Sprintf("repos/%s/%s/actions/runs/%d/jobs", owner, repo, runID))
+}
 
-	cmd := exec.Command("gh", "api", endpoint) // #nosec G204 -- fixed binary, owner/repo validated by checkOwnerRepo, no shell involved
-	output, err := cmd.Output()
+// FetchAttemptJobs returns the jobs of one attempt of a re-run workflow.
+func FetchAttemptJobs(ctx context.Context, owner, repo string, runID int64, attempt int) ([]Job, error) {
+	if err := checkOwnerRepo(owner, repo); err != nil {
+		return nil, err
+	}
+	return fetchJobs(ctx, fmt.Sprintf("repos/%s/%s/actions/runs/%d/attempts/%d/jobs", owner, repo, runID, attempt))
+}
+
+func fetchJobs(ctx context.Context, endpoint string) ([]Job, error) {
+	output, err := ghAPI(ctx, endpoint)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("gh api failed: %s", string(exitErr.Stderr))
-		}
-		return nil, fmt.Errorf("gh api failed: %w", err)
+		return nil, err
 	}
 
 	var response jobsResponse
@@ -180,6 +346,87 @@ func FetchRunJobs(owner, repo string, runID int64) ([]Job, error) {
 	return response.Jobs, nil
 }
 
+// FetchViewerLogin returns the login of the user gh is authenticated as.
+func FetchViewerLogin(ctx context.Context) (string, error) {
+	output, err := ghAPI(ctx, "user")
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Login string `json:"login"`
+	}
+	if err := json.Unmarshal(output, &response); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	return response.Login, nil
+}
+
+// RerunRun re-runs a completed run, or only its failed jobs.
+func RerunRun(ctx context.Context, owner, repo string, runID int64, failedOnly bool) error {
+	if err := checkOwnerRepo(owner, repo); err != nil {
+		return err
+	}
+	action := "rerun"
+	if failedOnly {
+		action = "rerun-failed-jobs"
+	}
+	_, err := ghAPI(ctx, "-X", "POST", fmt.Sprintf("repos/%s/%s/actions/runs/%d/%s", owner, repo, runID, action))
+	return err
+}
+
+// RateLimit is the API quota of one resource, such as "core" or "graphql".
+type RateLimit struct {
+	Limit     int   `json:"limit"`
+	Remaining int   `json:"remaining"`
+	Used      int   `json:"used"`
+	Reset     int64 `json:"reset"` // Unix seconds
+}
+
+// FetchRateLimits returns the quota of each API resource. Checking it
+// doesn't count against any of them.
+func FetchRateLimits(ctx context.Context) (map[string]RateLimit, error) {
+	output, err := ghAPI(ctx, "rate_limit")
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Resources map[string]RateLimit `json:"resources"`
+	}
+	if err := json.Unmarshal(output, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return response.Resources, nil
+}
+
+// ghAPI runs `gh api` with the given arguments and returns its stdout.
+// The process is killed when ctx is done or the request timeout expires.
+func ghAPI(ctx context.Context, args ...string) ([]byte, error) {
+	if offline {
+		return nil, ErrOffline
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "gh", append([]string{"api"}, args...)...) // #nosec G204 -- fixed binary, callers validate owner/repo with checkOwnerRepo, no shell involved
+	output, err := cmd.Output()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("gh api timed out after %s", requestTimeout)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return output, fmt.Errorf("gh api failed: %s", string(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("gh api failed: %w", err)
+	}
+	return output, nil
+}
+
 func IsGHInstalled() bool {
 	_, err := exec.LookPath("gh")
 	return err == nil
@@ -189,3 +436,15 @@ func IsAuthenticated() bool {
 	cmd := exec.Command("gh", "auth", "status")
 	return cmd.Run() == nil
 }
+
+// CheckCLI returns an error explaining what to do unless gh is installed
+// and logged in.
+func CheckCLI() error {
+	if !IsGHInstalled() {
+		return errors.New("gh CLI is not installed.\nPlease install it from: https://cli.github.com/")
+	}
+	if !IsAuthenticated() {
+		return errors.New("gh CLI is not authenticated.\nPlease run: gh auth login")
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// actionsAppID is the GitHub App ID of GitHub Actions. Filtering check
+// suites by it skips suites created by other CI integrations.
+const actionsAppID = 15368
+
+// RepoRef identifies a repository in a batch request.
+type RepoRef struct {
+	Owner string
+	Name  string
+}
+
+func (r RepoRef) String() string {
+	return r.Owner + "/" + r.Name
+}
+
+type graphQLResponse struct {
+	Data   map[string]*graphQLRepository `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type graphQLRepository struct {
+	DefaultBranchRef *struct {
+		Name   string `json:"name"`
+		Target struct {
+			History struct {
+				Nodes []struct {
+					OID         string `json:"oid"`
+					CheckSuites struct {
+						Nodes []graphQLCheckSuite `json:"nodes"`
+					} `json:"checkSuites"`
+				} `json:"nodes"`
+			} `json:"history"`
+		} `json:"target"`
+	} `json:"defaultBranchRef"`
+}
+
+type graphQLCheckSuite struct {
+	Status     string    `json:"status"`
+	Conclusion string    `json:"conclusion"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	Branch     *struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Creator *struct {
+		Login string `json:"login"`
+	} `json:"creator"`
+	WorkflowRun *struct {
+		DatabaseID int64     `json:"databaseId"`
+		RunNumber  int       `json:"runNumber"`
+		URL        string    `json:"url"`
+		CreatedAt  time.Time `json:"createdAt"`
+		UpdatedAt  time.Time `json:"updatedAt"`
+		Workflow   struct {
+			Name string `json:"name"`
+		} `json:"workflow"`
+	} `json:"workflowRun"`
+}
+
+// buildBatchQuery builds one GraphQL query with an aliased repository
+// field (r0, r1, ...) per repo. Each alias walks the latest commits on the
+// default branch and collects the Actions check suites attached to them.
+func buildBatchQuery(repos []RepoRef, limit int) string {
+	var b strings.Builder
+	b.WriteString("query {\n")
+	for i, r := range repos {
+		fmt.Fprintf(&b, `  r%d: repository(owner: %q, name: %q) {
+    defaultBranchRef {
+      name
+      target {
+        ... on Commit {
+          history(first: %d) {
+            nodes {
+              oid
+              checkSuites(first: 10, filterBy: {appId: %d}) {
+                nodes {
+                  status
+                  conclusion
+                  createdAt
+                  updatedAt
+                  branch { name }
+                  creator { login }
+                  workflowRun {
+                    databaseId
+                    runNumber
+                    url
+                    createdAt
+                    updatedAt
+                    workflow { name }
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+`, i, r.Owner, r.Name, limit, actionsAppID)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// BranchRuns is the latest workflow runs on a repo's default branch.
+type BranchRuns struct {
+	Branch string
+	Runs   []WorkflowRun
+}
+
+// FetchLatestRunsBatch fetches the most recent workflow runs for every repo
+// in a single GraphQL request. Runs come from the check suites on the latest
+// commits of each repo's default branch, so this only stands in for
+// FetchRuns filtered to that branch. Repos missing from the result (not
+// found, no default branch, or a partial GraphQL error) should be fetched
+// individually.
+func FetchLatestRunsBatch(ctx context.Context, repos []RepoRef, limit int) (map[RepoRef]BranchRuns, error) {
+	if len(repos) == 0 {
+		return map[RepoRef]BranchRuns{}, nil
+	}
+	for _, r := range repos {
+		if err := checkOwnerRepo(r.Owner, r.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	output, err := ghAPI(ctx, "graphql", "-f", "query="+buildBatchQuery(repos, limit))
+	// gh exits non-zero when the response carries any GraphQL error, even if
+	// most aliases resolved. Keep whatever data came back.
+	if err != nil && len(output) == 0 {
+		return nil, err
+	}
+	results, parseErr := parseBatchResponse(output, repos, limit)
+	if parseErr != nil && err != nil {
+		return nil, err
+	}
+	return results, parseErr
+}
+
+// parseBatchResponse picks each repo's runs out of the response to
+// buildBatchQuery(repos, limit).
+func parseBatchResponse(output []byte, repos []RepoRef, limit int) (map[RepoRef]BranchRuns, error) {
+	var response graphQLResponse
+	if err := json.Unmarshal(output, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if response.Data == nil {
+		if len(response.Errors) > 0 {
+			return nil, fmt.Errorf("graphql query failed: %s", response.Errors[0].Message)
+		}
+		return nil, fmt.Errorf("graphql query returned no data")
+	}
+
+	results := make(map[RepoRef]BranchRuns, len(repos))
+	for i, r := range repos {
+		repoData := response.Data[fmt.Sprintf("r%d", i)]
+		if repoData == nil || repoData.DefaultBranchRef == nil {
+			continue
+		}
+		results[r] = BranchRuns{
+			Branch: repoData.DefaultBranchRef.Name,
+			Runs:   repoData.workflowRuns(limit),
+		}
+	}
+	return results, nil
+}
+
+func (r *graphQLRepository) workflowRuns(limit int) []WorkflowRun {
+	runs := []WorkflowRun{}
+	for _, commit := range r.DefaultBranchRef.Target.History.Nodes {
+		for _, suite := range commit.CheckSuites.Nodes {
+			if suite.WorkflowRun == nil {
+				continue
+			}
+			branch := ""
+			if suite.Branch != nil {
+				branch = suite.Branch.Name
+			}
+			var actor Actor
+			if suite.Creator != nil {
+				actor.Login = suite.Creator.Login
+			}
+			runs = append(runs, WorkflowRun{
+				ID:           suite.WorkflowRun.DatabaseID,
+				Name:         suite.WorkflowRun.Workflow.Name,
+				HeadBranch:   branch,
+				HeadSHA:      commit.OID,
+				Status:       strings.ToLower(suite.Status),
+				Conclusion:   strings.ToLower(suite.Conclusion),
+				CreatedAt:    suite.WorkflowRun.CreatedAt,
+				UpdatedAt:    suite.WorkflowRun.UpdatedAt,
+				HTMLURL:      suite.WorkflowRun.URL,
+				RunNumber:    suite.WorkflowRun.RunNumber,
+				WorkflowName: suite.WorkflowRun.Workflow.Name,
+				Actor:        actor,
+			})
+		}
+	}
+
+	// Newest first, matching the REST endpoint's ordering
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+	})
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs
+}
@@ -0,0 +1,154 @@
+package github
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildBatchQuery(t *testing.T) {
+	repos := []RepoRef{{"acme", "app"}, {"acme", "lib"}}
+	query := buildBatchQuery(repos, 20)
+
+	for _, want := range []string{
+		`r0: repository(owner: "acme", name: "app")`,
+		`r1: repository(owner: "acme", name: "lib")`,
+		"history(first: 20)",
+		"filterBy: {appId: 15368}",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query is missing %q:\n%s", want, query)
+		}
+	}
+	if n := strings.Count(query, "defaultBranchRef {\n      name\n"); n != len(repos) {
+		t.Errorf("query asks for %d default branch names, want %d", n, len(repos))
+	}
+	if strings.Count(query, "{") != strings.Count(query, "}") {
+		t.Errorf("unbalanced braces:\n%s", query)
+	}
+}
+
+const batchResponse = `{
+  "data": {
+    "r0": {
+      "defaultBranchRef": {
+        "name": "main",
+        "target": {
+          "history": {
+            "nodes": [
+              {
+                "oid": "bbb",
+                "checkSuites": {
+                  "nodes": [
+                    {
+                      "status": "IN_PROGRESS",
+                      "conclusion": null,
+                      "branch": {"name": "main"},
+                      "creator": {"login": "octocat"},
+                      "workflowRun": {
+                        "databaseId": 2,
+                        "runNumber": 8,
+                        "url": "https://github.com/acme/app/actions/runs/2",
+                        "createdAt": "2026-10-18T12:00:00Z",
+                        "updatedAt": "2026-10-18T12:01:00Z",
+                        "workflow": {"name": "CI"}
+                      }
+                    },
+                    {"status": "COMPLETED", "conclusion": "SUCCESS", "workflowRun": null}
+                  ]
+                }
+              },
+              {
+                "oid": "aaa",
+                "checkSuites": {
+                  "nodes": [
+                    {
+                      "status": "COMPLETED",
+                      "conclusion": "FAILURE",
+                      "branch": {"name": "main"},
+                      "workflowRun": {
+                        "databaseId": 1,
+                        "runNumber": 7,
+                        "createdAt": "2026-10-18T11:00:00Z",
+                        "updatedAt": "2026-10-18T11:05:00Z",
+                        "workflow": {"name": "CI"}
+                      }
+                    },
+                    {
+                      "status": "COMPLETED",
+                      "conclusion": "SUCCESS",
+                      "branch": {"name": "main"},
+                      "workflowRun": {
+                        "databaseId": 3,
+                        "runNumber": 2,
+                        "createdAt": "2026-10-18T11:30:00Z",
+                        "updatedAt": "2026-10-18T11:32:00Z",
+                        "workflow": {"name": "Lint"}
+                      }
+                    }
+                  ]
+                }
+              }
+            ]
+          }
+        }
+      }
+    },
+    "r1": null,
+    "r2": {"defaultBranchRef": null}
+  },
+  "errors": [{"message": "Could not resolve to a Repository with the name 'acme/gone'."}]
+}`
+
+func TestParseBatchResponse(t *testing.T) {
+	repos := []RepoRef{{"acme", "app"}, {"acme", "gone"}, {"acme", "empty"}}
+	results, err := parseBatchResponse([]byte(batchResponse), repos, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("got results for %d repos, want only acme/app: %v", len(results), results)
+	}
+	got, ok := results[repos[0]]
+	if !ok {
+		t.Fatal("no result for acme/app")
+	}
+	if got.Branch != "main" {
+		t.Errorf("Branch = %q, want main", got.Branch)
+	}
+
+	// Newest first across commits, cut to the limit, suites without a
+	// workflow run skipped
+	if len(got.Runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(got.Runs))
+	}
+	first, second := got.Runs[0], got.Runs[1]
+	if first.ID != 2 || second.ID != 3 {
+		t.Errorf("run IDs = %d, %d, want 2, 3", first.ID, second.ID)
+	}
+	if first.Status != "in_progress" || first.Conclusion != "" {
+		t.Errorf("status, conclusion = %q, %q, want in_progress and none", first.Status, first.Conclusion)
+	}
+	if first.HeadBranch != "main" || first.HeadSHA != "bbb" || first.Actor.Login != "octocat" {
+		t.Errorf("branch, sha, actor = %q, %q, %q", first.HeadBranch, first.HeadSHA, first.Actor.Login)
+	}
+	if first.Name != "CI" || first.WorkflowName != "CI" || first.RunNumber != 8 {
+		t.Errorf("name, workflow, number = %q, %q, %d", first.Name, first.WorkflowName, first.RunNumber)
+	}
+	if second.Conclusion != "success" {
+		t.Errorf("Conclusion = %q, want success", second.Conclusion)
+	}
+}
+
+func TestParseBatchResponseErrors(t *testing.T) {
+	repos := []RepoRef{{"acme", "app"}}
+
+	_, err := parseBatchResponse([]byte(`{"data": null, "errors": [{"message": "bad credentials"}]}`), repos, 20)
+	if err == nil || !strings.Contains(err.Error(), "bad credentials") {
+		t.Errorf("err = %v, want the GraphQL error", err)
+	}
+
+	if _, err := parseBatchResponse([]byte(`not json`), repos, 20); err == nil {
+		t.Error("want an error for a malformed response")
+	}
+}
@@ -0,0 +1,120 @@
+package github
+
+import (
+	"context"
+	"sync"
+)
+
+// Scheduler limits how many API requests run at once and coalesces
+// identical requests: a caller asking for a key that is already in flight
+// waits for that request instead of starting another one.
+//
+// Every request gets a sequence number from one counter, so results for
+// the same thing fetched under different keys (say, one repo alone and as
+// part of a batch) can still be ordered by comparing them.
+type Scheduler struct {
+	sem chan struct{}
+
+	mu    sync.Mutex
+	calls map[string]*call
+	seq   uint64
+}
+
+type call struct {
+	done    chan struct{}
+	val     any
+	err     error
+	seq     uint64
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func NewScheduler(maxInFlight int) *Scheduler {
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+	return &Scheduler{
+		sem:   make(chan struct{}, maxInFlight),
+		calls: make(map[string]*call),
+	}
+}
+
+// Do runs fn for key, or joins the in-flight request for the same key.
+// It returns fn's result along with the sequence number of the request
+// that produced it. The request doesn't belong to any one caller: each
+// stops waiting when its own ctx ends, and the request is cancelled once
+// none are left.
+func (s *Scheduler) Do(ctx context.Context, key string, fn func(context.Context) (any, error)) (any, uint64, error) {
+	s.mu.Lock()
+	c, ok := s.calls[key]
+	if !ok {
+		s.seq++
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &call{done: make(chan struct{}), seq: s.seq, cancel: cancel}
+		s.calls[key] = c
+		go s.run(callCtx, key, c, fn)
+	}
+	c.waiters++
+	s.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.seq, c.err
+	case <-ctx.Done():
+		s.leave(key, c)
+		return nil, c.seq, ctx.Err()
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, key string, c *call, fn func(context.Context) (any, error)) {
+	defer c.cancel()
+	select {
+	case s.sem <- struct{}{}:
+		c.val, c.err = fn(ctx)
+		<-s.sem
+	case <-ctx.Done():
+		c.err = ctx.Err()
+	}
+
+	s.mu.Lock()
+	if s.calls[key] == c {
+		delete(s.calls, key)
+	}
+	s.mu.Unlock()
+	close(c.done)
+}
+
+// leave drops a caller that stopped waiting for c. The last one to go
+// cancels the request, and a caller arriving after that starts afresh.
+func (s *Scheduler) leave(key string, c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	c.cancel()
+	if s.calls[key] == c {
+		delete(s.calls, key)
+	}
+}
+
+// Next hands out a sequence number for a result that didn't come through
+// Do, such as one pushed to us, so it orders against fetched ones.
+func (s *Scheduler) Next() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	return s.seq
+}
+
+// Superseded reports whether a newer request for key is in flight than
+// the one that produced seq, so its result is on the way. (A request
+// everyone gave up on may still be winding down, but nobody gets its
+// result.)
+func (s *Scheduler) Superseded(key string, seq uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.calls[key]
+	return ok && c.seq > seq
+}
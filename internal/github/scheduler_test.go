@@ -0,0 +1,167 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerCap(t *testing.T) {
+	s := NewScheduler(2)
+	var running, most atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, _ = s.Do(context.Background(), fmt.Sprint(i), func(context.Context) (any, error) {
+				n := running.Add(1)
+				for {
+					m := most.Load()
+					if n <= m || most.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				running.Add(-1)
+				return nil, nil
+			})
+		}()
+	}
+	wg.Wait()
+	if n := most.Load(); n != 2 {
+		t.Errorf("at most %d requests ran at once, want 2", n)
+	}
+}
+
+func TestSchedulerCoalesces(t *testing.T) {
+	s := NewScheduler(4)
+	release := make(chan struct{})
+	var calls atomic.Int32
+	fn := func(context.Context) (any, error) {
+		calls.Add(1)
+		<-release
+		return "runs", nil
+	}
+
+	type result struct {
+		val any
+		seq uint64
+	}
+	results := make(chan result, 3)
+	for range 3 {
+		go func() {
+			val, seq, _ := s.Do(context.Background(), "key", fn)
+			results <- result{val, seq}
+		}()
+	}
+	waitWaiters(t, s, "key", 3)
+	close(release)
+
+	first := <-results
+	for range 2 {
+		if r := <-results; r != first {
+			t.Errorf("got %v, want %v like the first caller", r, first)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("fn ran %d times, want 1", n)
+	}
+}
+
+func TestSchedulerLeaderCancelled(t *testing.T) {
+	s := NewScheduler(1)
+	release := make(chan struct{})
+	fnCtx := make(chan context.Context, 1)
+	fn := func(ctx context.Context) (any, error) {
+		fnCtx <- ctx
+		select {
+		case <-release:
+			return "runs", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, _, err := s.Do(leaderCtx, "key", fn)
+		leaderErr <- err
+	}()
+	ctx := <-fnCtx
+
+	joined := make(chan any, 1)
+	go func() {
+		val, _, _ := s.Do(context.Background(), "key", fn)
+		joined <- val
+	}()
+	waitWaiters(t, s, "key", 2)
+
+	// The caller that started the request leaves; the other still gets
+	// the result
+	cancelLeader()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader err = %v, want context.Canceled", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("request was cancelled while a caller still waited")
+	}
+	close(release)
+	if val := <-joined; val != "runs" {
+		t.Errorf("joined caller got %v, want runs", val)
+	}
+}
+
+func TestSchedulerAllLeave(t *testing.T) {
+	s := NewScheduler(1)
+	fnCtx := make(chan context.Context, 1)
+	fn := func(ctx context.Context) (any, error) {
+		fnCtx <- ctx
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_, _, _ = s.Do(ctx, "key", fn)
+		close(done)
+	}()
+	reqCtx := <-fnCtx
+	cancel()
+	<-done
+
+	select {
+	case <-reqCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("request kept running with nobody waiting")
+	}
+
+	// A new caller doesn't join the abandoned request
+	val, _, err := s.Do(context.Background(), "key", func(context.Context) (any, error) { return "fresh", nil })
+	if err != nil || val != "fresh" {
+		t.Errorf("got %v, %v, want a fresh request", val, err)
+	}
+}
+
+// waitWaiters waits until n callers wait on the request for key.
+func waitWaiters(t *testing.T, s *Scheduler, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		c, ok := s.calls[key]
+		waiting := ok && c.waiters == n
+		s.mu.Unlock()
+		if waiting {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("never saw %d callers waiting on %s", n, key)
+}
@@ -0,0 +1,411 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+// Store records every run and job ghflow has seen. Each repo gets an
+// append-only JSON Lines file under the state dir. A line is written only
+// when a run or job is new or its status changed, so the file doubles as
+// a log of status transitions. Once a repo has well over MaxRuns runs on
+// record, its file is rewritten with only the newest MaxRuns.
+type Store struct {
+	dir string
+
+	mu sync.Mutex
+	// seen caches the last recorded state per repo so we don't have to
+	// re-read the file on every refresh. Loaded lazily from disk.
+	seen map[string]*repoState
+}
+
+// MaxRuns is how many runs per repo the store keeps.
+const MaxRuns = 1000
+
+type repoState struct {
+	runs    map[int64]string
+	jobs    map[int64]string
+	jobRuns map[int64]jobRun // Job ID -> the run attempt it belongs to
+}
+
+type jobRun struct {
+	id      int64
+	attempt int
+}
+
+// Entry is one line of a repo's history file.
+type Entry struct {
+	SeenAt time.Time           `json:"seen_at"`
+	Run    *github.WorkflowRun `json:"run,omitempty"`
+	Job    *github.Job         `json:"job,omitempty"`
+}
+
+// Transition is a status change observed at a point in time.
+type Transition struct {
+	Status     string    `json:"status"`
+	Conclusion string    `json:"conclusion,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// RunRecord is everything known about one run: its latest state, how its
+// status changed over time, and the jobs seen for it.
+type RunRecord struct {
+	Run         github.WorkflowRun
+	Transitions []Transition
+	Jobs        []JobRecord
+}
+
+type JobRecord struct {
+	Job         github.Job
+	Transitions []Transition
+}
+
+// Open opens the store in the default state directory.
+func Open() (*Store, error) {
+	dir, err := config.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	return OpenDir(filepath.Join(dir, "history"))
+}
+
+// OpenDir opens a store rooted at dir, creating it if needed.
+func OpenDir(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Store{
+		dir:  dir,
+		seen: make(map[string]*repoState),
+	}, nil
+}
+
+// repoPath maps "owner/name" to its history file, refusing anything that
+// could point outside the store.
+func (s *Store) repoPath(repo string) (string, error) {
+	return config.RepoFile(s.dir, repo, ".jsonl")
+}
+
+func stateKey(status, conclusion string) string {
+	return status + "/" + conclusion
+}
+
+// RecordRuns appends runs that are new or changed status since they were
+// last recorded.
+func (s *Store) RecordRuns(repo string, runs []github.WorkflowRun) error {
+	now := time.Now()
+	return s.record(repo, func(state *repoState) []Entry {
+		var entries []Entry
+		for i := range runs {
+			run := runs[i]
+			if state.runs[run.ID] != stateKey(run.Status, run.Conclusion) {
+				entries = append(entries, Entry{SeenAt: now, Run: &run})
+			}
+		}
+		return entries
+	})
+}
+
+// RecordJobs appends jobs that are new or changed status since they were
+// last recorded.
+func (s *Store) RecordJobs(repo string, jobs []github.Job) error {
+	now := time.Now()
+	return s.record(repo, func(state *repoState) []Entry {
+		var entries []Entry
+		for i := range jobs {
+			job := jobs[i]
+			if state.jobs[job.ID] != stateKey(job.Status, job.Conclusion) {
+				entries = append(entries, Entry{SeenAt: now, Job: &job})
+			}
+		}
+		return entries
+	})
+}
+
+// WithoutJobs returns the runs whose latest attempt doesn't have its
+// finished jobs on record: none were recorded, or some only while they
+// were still running.
+func (s *Store) WithoutJobs(repo string, runs []github.WorkflowRun) ([]github.WorkflowRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.stateLocked(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := map[jobRun]bool{} // Run attempt -> all its jobs completed
+	for id, r := range state.jobRuns {
+		done, ok := recorded[r]
+		recorded[r] = (done || !ok) && strings.HasPrefix(state.jobs[id], "completed/")
+	}
+	var missing []github.WorkflowRun
+	for _, run := range runs {
+		if !recorded[jobRun{run.ID, max(run.RunAttempt, 1)}] {
+			missing = append(missing, run)
+		}
+	}
+	return missing, nil
+}
+
+// record appends the entries fresh picks out against the repo's last
+// recorded state. The state only takes them in once they're on disk, so
+// a failed write is retried on the next call.
+func (s *Store) record(repo string, fresh func(*repoState) []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.stateLocked(repo)
+	if err != nil {
+		return err
+	}
+	entries := fresh(state)
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := s.appendLocked(repo, entries); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		state.add(e)
+	}
+	if len(state.runs) > MaxRuns+MaxRuns/10 {
+		return s.compactLocked(repo)
+	}
+	return nil
+}
+
+// stateLocked returns the repo's cached state, loading it from disk the
+// first time the repo is touched.
+func (s *Store) stateLocked(repo string) (*repoState, error) {
+	if state, ok := s.seen[repo]; ok {
+		return state, nil
+	}
+	entries, err := s.readLocked(repo)
+	if err != nil {
+		return nil, err
+	}
+	state := newRepoState(entries)
+	s.seen[repo] = state
+	return state, nil
+}
+
+func newRepoState(entries []Entry) *repoState {
+	state := &repoState{runs: map[int64]string{}, jobs: map[int64]string{}, jobRuns: map[int64]jobRun{}}
+	for _, e := range entries {
+		state.add(e)
+	}
+	return state
+}
+
+func (state *repoState) add(e Entry) {
+	if e.Run != nil {
+		state.runs[e.Run.ID] = stateKey(e.Run.Status, e.Run.Conclusion)
+	}
+	if e.Job != nil {
+		state.jobs[e.Job.ID] = stateKey(e.Job.Status, e.Job.Conclusion)
+		state.jobRuns[e.Job.ID] = jobRun{e.Job.RunID, max(e.Job.RunAttempt, 1)}
+	}
+}
+
+func (s *Store) appendLocked(repo string, entries []Entry) error {
+	path, err := s.repoPath(repo)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 -- path built by repoPath, which rejects separators and dot segments
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compactLocked rewrites the repo's file with only the newest MaxRuns runs
+// and their jobs.
+func (s *Store) compactLocked(repo string) error {
+	entries, err := s.readLocked(repo)
+	if err != nil {
+		return err
+	}
+
+	created := map[int64]time.Time{}
+	for _, e := range entries {
+		if e.Run != nil {
+			created[e.Run.ID] = e.Run.CreatedAt
+		}
+	}
+	ids := make([]int64, 0, len(created))
+	for id := range created {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return created[ids[i]].After(created[ids[j]])
+	})
+	keep := map[int64]bool{}
+	for _, id := range ids[:min(len(ids), MaxRuns)] {
+		keep[id] = true
+	}
+
+	kept := entries[:0]
+	for _, e := range entries {
+		if (e.Run != nil && keep[e.Run.ID]) || (e.Job != nil && keep[e.Job.RunID]) {
+			kept = append(kept, e)
+		}
+	}
+
+	path, err := s.repoPath(repo)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	enc := json.NewEncoder(tmp)
+	for _, e := range kept {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	s.seen[repo] = newRepoState(kept)
+	return nil
+}
+
+// Entries returns the raw history for repo, oldest first.
+func (s *Store) Entries(repo string) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readLocked(repo)
+}
+
+func (s *Store) readLocked(repo string) ([]Entry, error) {
+	path, err := s.repoPath(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path) // #nosec G304 -- path built by repoPath, which rejects separators and dot segments
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// A torn write from a crash shouldn't make the whole history
+			// unreadable
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Runs folds the history for repo into one record per run, newest first.
+func (s *Store) Runs(repo string) ([]RunRecord, error) {
+	entries, err := s.Entries(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[int64]*RunRecord{}
+	jobsByRun := map[int64]map[int64]*JobRecord{}
+	for _, e := range entries {
+		if e.Run != nil {
+			rec, ok := byID[e.Run.ID]
+			if !ok {
+				rec = &RunRecord{}
+				byID[e.Run.ID] = rec
+			}
+			rec.Run = *e.Run
+			rec.Transitions = append(rec.Transitions, Transition{
+				Status:     e.Run.Status,
+				Conclusion: e.Run.Conclusion,
+				At:         e.SeenAt,
+			})
+		}
+		if e.Job != nil {
+			jobs, ok := jobsByRun[e.Job.RunID]
+			if !ok {
+				jobs = map[int64]*JobRecord{}
+				jobsByRun[e.Job.RunID] = jobs
+			}
+			rec, ok := jobs[e.Job.ID]
+			if !ok {
+				rec = &JobRecord{}
+				jobs[e.Job.ID] = rec
+			}
+			rec.Job = *e.Job
+			rec.Transitions = append(rec.Transitions, Transition{
+				Status:     e.Job.Status,
+				Conclusion: e.Job.Conclusion,
+				At:         e.SeenAt,
+			})
+		}
+	}
+
+	records := make([]RunRecord, 0, len(byID))
+	for id, rec := range byID {
+		for _, job := range jobsByRun[id] {
+			rec.Jobs = append(rec.Jobs, *job)
+		}
+		sort.Slice(rec.Jobs, func(i, j int) bool {
+			return rec.Jobs[i].Job.ID < rec.Jobs[j].Job.ID
+		})
+		records = append(records, *rec)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Run.CreatedAt.After(records[j].Run.CreatedAt)
+	})
+	return records, nil
+}
+
+// LatestRuns returns the last known state of the newest limit runs.
+func (s *Store) LatestRuns(repo string, limit int) ([]github.WorkflowRun, error) {
+	records, err := s.Runs(repo)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	runs := make([]github.WorkflowRun, len(records))
+	for i, rec := range records {
+		runs[i] = rec.Run
+	}
+	return runs, nil
+}
@@ -0,0 +1,60 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+func TestCompaction(t *testing.T) {
+	store, err := OpenDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	total := MaxRuns + MaxRuns/10 + 1
+	for i := range total {
+		run := github.WorkflowRun{ID: int64(i + 1), Status: "completed", Conclusion: "success", CreatedAt: start.Add(time.Duration(i) * time.Minute)}
+		job := github.Job{ID: int64(i + 1), RunID: run.ID, Status: "completed", Conclusion: "success"}
+		if err := store.RecordRuns("acme/app", []github.WorkflowRun{run}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.RecordJobs("acme/app", []github.Job{job}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := store.Runs("acme/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compacted down to MaxRuns when the last run pushed it over, then
+	// that run's job was appended
+	if len(records) != MaxRuns {
+		t.Fatalf("%d runs on record, want %d", len(records), MaxRuns)
+	}
+	if newest, oldest := records[0].Run.ID, records[len(records)-1].Run.ID; newest != int64(total) || oldest != int64(total-MaxRuns+1) {
+		t.Errorf("kept runs %d..%d, want %d..%d", oldest, newest, total-MaxRuns+1, total)
+	}
+	for _, rec := range records {
+		if len(rec.Jobs) != 1 {
+			t.Fatalf("run %d has %d jobs, want 1", rec.Run.ID, len(rec.Jobs))
+		}
+	}
+
+	// A store opened afresh sees the same state and records nothing twice
+	reopened, err := OpenDir(store.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, _ := reopened.Entries("acme/app")
+	if err := reopened.RecordRuns("acme/app", []github.WorkflowRun{records[0].Run}); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := reopened.Entries("acme/app")
+	if len(after) != len(before) {
+		t.Errorf("re-recording an unchanged run appended %d entries", len(after)-len(before))
+	}
+}
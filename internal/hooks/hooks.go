@@ -0,0 +1,287 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/notify"
+)
+
+// Events hook commands can be attached to.
+const (
+	RunStarted   = "run_started"
+	RunSucceeded = "run_succeeded"
+	RunFailed    = "run_failed"
+	JobFailed    = "job_failed"
+	QueueTimeout = "queue_timeout"
+)
+
+const (
+	DefaultTimeout      = 30 * time.Second
+	DefaultQueueTimeout = 10 * time.Minute
+
+	// firedWindow is how long an event is remembered so it fires once.
+	firedWindow = 24 * time.Hour
+
+	// maxLoggedOutput caps how much of a command's output is logged.
+	maxLoggedOutput = 16 << 10
+)
+
+// Payload is what a hook command receives as JSON on stdin.
+type Payload struct {
+	Event string              `json:"event"`
+	Repo  string              `json:"repo"`
+	Path  string              `json:"path,omitempty"` // Local checkout
+	Run   *github.WorkflowRun `json:"run,omitempty"`
+	Job   *github.Job         `json:"job,omitempty"`
+}
+
+// Runner starts the commands configured for each event.
+type Runner struct {
+	on           map[string][]string
+	timeout      time.Duration
+	queueTimeout time.Duration
+	log          *log.Logger
+
+	mu    sync.Mutex
+	fired map[string]time.Time
+}
+
+// NewRunner checks cfg and opens the debug log in the state directory.
+func NewRunner(cfg config.HooksConfig) (*Runner, error) {
+	for event := range cfg.On {
+		switch event {
+		case RunStarted, RunSucceeded, RunFailed, JobFailed, QueueTimeout:
+		default:
+			return nil, fmt.Errorf("unknown hook event %q: use run_started, run_succeeded, run_failed, job_failed or queue_timeout", event)
+		}
+	}
+
+	dir, err := config.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "hooks.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600) // #nosec G304 -- fixed name under the state dir
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Runner{
+		on:           cfg.On,
+		timeout:      time.Duration(cfg.Timeout) * time.Second,
+		queueTimeout: time.Duration(cfg.QueueTimeout) * time.Second,
+		log:          log.New(f, "", log.LstdFlags),
+		fired:        map[string]time.Time{},
+	}
+	if r.timeout <= 0 {
+		r.timeout = DefaultTimeout
+	}
+	if r.queueTimeout <= 0 {
+		r.queueTimeout = DefaultQueueTimeout
+	}
+	return r, nil
+}
+
+// Transitions fires the hooks for run transitions found by notify.Detect.
+// When a run failed and job_failed has commands, the run's jobs are
+// fetched to find which ones failed.
+func (r *Runner) Transitions(ctx context.Context, repo config.Repo, events []notify.Event) {
+	for _, e := range events {
+		run := e.Run
+		p := Payload{Repo: repo.FullName(), Path: repo.Path, Run: &run}
+		switch e.Transition {
+		case notify.Started:
+			p.Event = RunStarted
+		case notify.Succeeded, notify.Fixed:
+			p.Event = RunSucceeded
+		case notify.Failed:
+			p.Event = RunFailed
+			if len(r.on[JobFailed]) > 0 {
+				go r.failedJobs(ctx, repo, run)
+			}
+		default:
+			continue
+		}
+		r.fire(ctx, p)
+	}
+}
+
+func (r *Runner) failedJobs(ctx context.Context, repo config.Repo, run github.WorkflowRun) {
+	jobs, err := github.FetchRunJobs(ctx, repo.Owner, repo.Name, run.ID)
+	if err != nil {
+		r.log.Printf("%s %s: fetching jobs of run %d: %v", JobFailed, repo.FullName(), run.ID, err)
+		return
+	}
+	for _, job := range jobs {
+		r.Job(ctx, repo, run, job)
+	}
+}
+
+// Job fires job_failed if job failed. run may be zero when only the job
+// is known.
+func (r *Runner) Job(ctx context.Context, repo config.Repo, run github.WorkflowRun, job github.Job) {
+	if job.Status != "completed" || (job.Conclusion != "failure" && job.Conclusion != "timed_out") {
+		return
+	}
+	p := Payload{Event: JobFailed, Repo: repo.FullName(), Path: repo.Path, Job: &job}
+	if run.ID != 0 {
+		p.Run = &run
+	}
+	r.fire(ctx, p)
+}
+
+// Queued fires queue_timeout for runs that have been queued too long.
+func (r *Runner) Queued(ctx context.Context, repo config.Repo, runs []github.WorkflowRun, now time.Time) {
+	for _, run := range runs {
+		if run.Status == "queued" && now.Sub(run.CreatedAt) >= r.queueTimeout {
+			r.fire(ctx, Payload{Event: QueueTimeout, Repo: repo.FullName(), Path: repo.Path, Run: &run})
+		}
+	}
+}
+
+// once reports whether p hasn't fired before, and marks it fired.
+func (r *Runner) once(p Payload) bool {
+	key := p.Event + ":" + p.Repo
+	switch {
+	case p.Job != nil:
+		// The same job comes from a webhook without its run and from a
+		// fetch with it, so the run can't be part of the key
+		key += fmt.Sprintf("/%d.%d", p.Job.ID, p.Job.RunAttempt)
+	case p.Run != nil:
+		key += fmt.Sprintf("#%d.%d", p.Run.ID, p.Run.RunAttempt)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for k, at := range r.fired {
+		if now.Sub(at) > firedWindow {
+			delete(r.fired, k)
+		}
+	}
+	if _, ok := r.fired[key]; ok {
+		return false
+	}
+	r.fired[key] = now
+	return true
+}
+
+// fire starts every command for p.Event in the background.
+func (r *Runner) fire(ctx context.Context, p Payload) {
+	commands := r.on[p.Event]
+	if len(commands) == 0 || !r.once(p) {
+		return
+	}
+	stdin, err := json.Marshal(p)
+	if err != nil {
+		r.log.Printf("%s %s: %v", p.Event, p.Repo, err)
+		return
+	}
+	env := append(os.Environ(), environ(p)...)
+	for _, command := range commands {
+		go r.run(ctx, p, command, env, stdin)
+	}
+}
+
+func (r *Runner) run(ctx context.Context, p Payload, command string, env []string, stdin []byte) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cmd := shell(ctx, command)
+	cmd.Env = env
+	cmd.Stdin = bytes.NewReader(stdin)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	// Children of the shell can keep the output pipe open after it's
+	// killed; don't wait on them
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	status := "ok"
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		status = fmt.Sprintf("killed after %s", r.timeout)
+	case err != nil:
+		status = err.Error()
+	}
+	output := out.Bytes()
+	if len(output) > maxLoggedOutput {
+		output = append(output[:maxLoggedOutput:maxLoggedOutput], "\n[truncated]"...)
+	}
+	r.log.Printf("%s %s: %q: %s in %s\n%s", p.Event, p.Repo, command, status, elapsed, indent(output))
+}
+
+// shell runs command through the platform's shell.
+func shell(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command) // #nosec G204 -- commands come from the user's own config
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command) // #nosec G204 -- commands come from the user's own config
+}
+
+// environ describes p as GHFLOW_* variables.
+func environ(p Payload) []string {
+	env := []string{
+		"GHFLOW_EVENT=" + p.Event,
+		"GHFLOW_REPO=" + p.Repo,
+		"GHFLOW_REPO_PATH=" + p.Path,
+	}
+	if run := p.Run; run != nil {
+		env = append(env,
+			"GHFLOW_RUN_ID="+strconv.FormatInt(run.ID, 10),
+			"GHFLOW_RUN_NUMBER="+strconv.Itoa(run.RunNumber),
+			"GHFLOW_RUN_ATTEMPT="+strconv.Itoa(run.RunAttempt),
+			"GHFLOW_WORKFLOW="+run.WorkflowName,
+			"GHFLOW_BRANCH="+run.HeadBranch,
+			"GHFLOW_SHA="+run.HeadSHA,
+			"GHFLOW_STATUS="+run.Status,
+			"GHFLOW_CONCLUSION="+run.Conclusion,
+			"GHFLOW_ACTOR="+run.Actor.Login,
+			"GHFLOW_URL="+run.HTMLURL,
+		)
+	}
+	if job := p.Job; job != nil {
+		env = append(env,
+			"GHFLOW_JOB_ID="+strconv.FormatInt(job.ID, 10),
+			"GHFLOW_JOB_NAME="+job.Name,
+			"GHFLOW_JOB_CONCLUSION="+job.Conclusion,
+		)
+		if p.Run == nil {
+			env = append(env,
+				"GHFLOW_RUN_ID="+strconv.FormatInt(job.RunID, 10),
+				"GHFLOW_WORKFLOW="+job.WorkflowName,
+				"GHFLOW_SHA="+job.HeadSHA,
+			)
+		}
+	}
+	return env
+}
+
+func indent(output []byte) string {
+	s := strings.TrimRight(string(output), "\n")
+	if s == "" {
+		return "  (no output)"
+	}
+	return "  " + strings.ReplaceAll(s, "\n", "\n  ")
+}
@@ -0,0 +1,179 @@
+package inbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+// DefaultDays is how far back the inbox looks unless configured.
+const DefaultDays = 7
+
+// Item is a run that needs attention.
+type Item struct {
+	Repo config.Repo
+	Run  github.WorkflowRun
+}
+
+// Key identifies one attempt of a run, so a re-run that fails again
+// shows up even if the first failure was acknowledged.
+func (it Item) Key() string {
+	return fmt.Sprintf("%s#%d.%d", it.Repo.FullName(), it.Run.ID, it.Run.RunAttempt)
+}
+
+// NeedsAttention reports whether run finished in a state someone has to
+// look at.
+func NeedsAttention(run github.WorkflowRun) bool {
+	if run.Status != "completed" {
+		return false
+	}
+	switch run.Conclusion {
+	case "failure", "startup_failure", "timed_out", "action_required":
+		return true
+	}
+	return false
+}
+
+// Failures returns the runs in runs that need attention and haven't been
+// fixed since: a newer run of the same workflow on the same branch that
+// succeeded resolves them. Runs may be in any order; the result is newest
+// first.
+func Failures(runs []github.WorkflowRun) []github.WorkflowRun {
+	sorted := make([]github.WorkflowRun, len(runs))
+	copy(sorted, runs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	fixed := map[string]bool{}
+	var failures []github.WorkflowRun
+	for _, run := range sorted {
+		key := run.WorkflowName + "\x00" + run.HeadBranch
+		if run.Status == "completed" && run.Conclusion == "success" {
+			fixed[key] = true
+			continue
+		}
+		if NeedsAttention(run) && !fixed[key] {
+			failures = append(failures, run)
+		}
+	}
+	return failures
+}
+
+// Sort orders items newest first.
+func Sort(items []Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Run.CreatedAt.After(items[j].Run.CreatedAt)
+	})
+}
+
+// State remembers which items were acknowledged or snoozed.
+type State struct {
+	path string
+
+	mu      sync.Mutex
+	Acked   map[string]time.Time `json:"acked"`   // key -> when
+	Snoozed map[string]time.Time `json:"snoozed"` // key -> until
+}
+
+// Open loads the inbox state from the default state directory.
+func Open() (*State, error) {
+	dir, err := config.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	return OpenFile(filepath.Join(dir, "inbox.json"))
+}
+
+// OpenFile loads the inbox state kept at path. A missing file is an empty
+// state.
+func OpenFile(path string) (*State, error) {
+	s := &State{
+		path:    path,
+		Acked:   map[string]time.Time{},
+		Snoozed: map[string]time.Time{},
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- path is fixed under the state dir
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Acked == nil {
+		s.Acked = map[string]time.Time{}
+	}
+	if s.Snoozed == nil {
+		s.Snoozed = map[string]time.Time{}
+	}
+	return s, nil
+}
+
+// Hidden reports whether item was acknowledged, or is snoozed at now.
+func (s *State) Hidden(item Item, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := item.Key()
+	if _, ok := s.Acked[key]; ok {
+		return true
+	}
+	return now.Before(s.Snoozed[key])
+}
+
+// Ack hides item for good.
+func (s *State) Ack(item Item) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Acked[item.Key()] = time.Now()
+	delete(s.Snoozed, item.Key())
+	return s.saveLocked()
+}
+
+// Snooze hides item until the given time.
+func (s *State) Snooze(item Item, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Snoozed[item.Key()] = until
+	return s.saveLocked()
+}
+
+// stateRetention is how long acknowledgements are kept. Runs older than
+// this fall out of the inbox window long before.
+const stateRetention = 90 * 24 * time.Hour
+
+func (s *State) saveLocked() error {
+	now := time.Now()
+	for key, at := range s.Acked {
+		if now.Sub(at) > stateRetention {
+			delete(s.Acked, key)
+		}
+	}
+	for key, until := range s.Snoozed {
+		if now.After(until) {
+			delete(s.Snoozed, key)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
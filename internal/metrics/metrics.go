@@ -0,0 +1,355 @@
+// Package metrics exposes CI health in the Prometheus text format.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/poll"
+	"github.com/thesimpledev/ghflow/internal/stats"
+)
+
+// ContentType is the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// statuses are every value of the status label, so each workflow always
+// has the same series and exactly one of them is 1.
+var statuses = []github.RunStatus{
+	github.StatusSuccess,
+	github.StatusFailure,
+	github.StatusInProgress,
+	github.StatusPending,
+	github.StatusCancelled,
+	github.StatusUnknown,
+}
+
+type workflowKey struct {
+	repo, workflow string
+}
+
+// totals accumulate over every completed run seen since startup.
+type totals struct {
+	conclusions   map[string]int
+	durationSum   float64
+	durationCount int
+	queueSum      float64
+	queueCount    int
+}
+
+// Exporter polls repos and keeps what the last poll found, plus counters
+// of completed runs.
+type Exporter struct {
+	repos []config.Repo
+
+	mu          sync.Mutex
+	results     []poll.Result
+	rateLimits  map[string]github.RateLimit
+	lastPoll    time.Time
+	pollTook    time.Duration
+	pollErrors  map[string]int // Repo -> failed fetches
+	totals      map[workflowKey]*totals
+	counted     map[string]map[string]bool // Repo -> run id.attempt already in totals
+	initialised bool
+}
+
+// NewExporter exports metrics for repos. Metrics are per repo and
+// workflow, so a repo listed more than once is polled once, and without
+// the branch or workflow filter of its dashboard cards.
+func NewExporter(repos []config.Repo) *Exporter {
+	var unique []config.Repo
+	listed := map[string]bool{}
+	for _, r := range repos {
+		key := strings.ToLower(r.FullName())
+		if listed[key] {
+			continue
+		}
+		listed[key] = true
+		unique = append(unique, config.Repo{Path: r.Path, Owner: r.Owner, Name: r.Name})
+	}
+	return &Exporter{
+		repos:      unique,
+		pollErrors: map[string]int{},
+		totals:     map[workflowKey]*totals{},
+		counted:    map[string]map[string]bool{},
+	}
+}
+
+// Run polls every interval until ctx is done, starting straight away.
+func (e *Exporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		e.Poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches every repo the way the dashboard does, and the rate limit.
+func (e *Exporter) Poll(ctx context.Context) {
+	start := time.Now()
+	results := poll.FetchAll(ctx, e.repos)
+	limits, err := github.FetchRateLimits(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.results = results
+	if err == nil {
+		e.rateLimits = limits
+	}
+	e.lastPoll = time.Now()
+	e.pollTook = e.lastPoll.Sub(start)
+	e.initialised = true
+	for _, r := range results {
+		name := r.Repo.FullName()
+		if r.Err != nil {
+			e.pollErrors[name]++
+			continue
+		}
+		e.count(name, r.Runs)
+	}
+}
+
+// count adds runs that completed since the last poll to the totals. Runs
+// are remembered only while they're in the fetched window, which they
+// never come back into once they've left. The first poll of a repo only
+// takes note of what has completed already: counting that backlog would
+// look like a burst of new runs after every restart.
+func (e *Exporter) count(repo string, runs []github.WorkflowRun) {
+	prev, polled := e.counted[repo]
+	seen := make(map[string]bool, len(runs))
+	for _, run := range runs {
+		if run.Status != "completed" {
+			continue
+		}
+		key := fmt.Sprintf("%d.%d", run.ID, run.RunAttempt)
+		seen[key] = true
+		if !polled || prev[key] {
+			continue
+		}
+
+		k := workflowKey{repo, run.WorkflowName}
+		t := e.totals[k]
+		if t == nil {
+			t = &totals{conclusions: map[string]int{}}
+			e.totals[k] = t
+		}
+		t.conclusions[run.Conclusion]++
+		if d := stats.RunDuration(run); d > 0 {
+			t.durationSum += d.Seconds()
+			t.durationCount++
+		}
+		if q := stats.QueueTime(run); q > 0 {
+			t.queueSum += q.Seconds()
+			t.queueCount++
+		}
+	}
+	e.counted[repo] = seen
+}
+
+// ServeHTTP serves the metrics on /metrics.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/metrics":
+		w.Header().Set("Content-Type", ContentType)
+		e.Write(w)
+	case "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><title>ghflow</title></head><body><a href="/metrics">Metrics</a></body></html>`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Write writes every metric in the text exposition format.
+func (e *Exporter) Write(w io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	m := &writer{w: w}
+
+	m.family("ghflow_up", "gauge", "Whether the last poll of the repo succeeded.")
+	for _, r := range e.results {
+		m.sample("ghflow_up", bool01(r.Err == nil), "repo", r.Repo.FullName())
+	}
+
+	m.family("ghflow_poll_errors_total", "counter", "Polls of the repo that failed.")
+	for _, repo := range e.repos {
+		m.sample("ghflow_poll_errors_total", float64(e.pollErrors[repo.FullName()]), "repo", repo.FullName())
+	}
+
+	if e.initialised {
+		m.family("ghflow_last_poll_timestamp_seconds", "gauge", "When the repos were last polled.")
+		m.sample("ghflow_last_poll_timestamp_seconds", float64(e.lastPoll.Unix()))
+		m.family("ghflow_poll_duration_seconds", "gauge", "How long the last poll took.")
+		m.sample("ghflow_poll_duration_seconds", e.pollTook.Seconds())
+	}
+
+	e.writeLatest(m)
+	e.writeTotals(m)
+
+	resources := make([]string, 0, len(e.rateLimits))
+	for name := range e.rateLimits {
+		resources = append(resources, name)
+	}
+	sort.Strings(resources)
+	m.family("ghflow_rate_limit_remaining", "gauge", "API requests left in the current rate limit window.")
+	for _, name := range resources {
+		m.sample("ghflow_rate_limit_remaining", float64(e.rateLimits[name].Remaining), "resource", name)
+	}
+	m.family("ghflow_rate_limit_limit", "gauge", "API requests allowed per rate limit window.")
+	for _, name := range resources {
+		m.sample("ghflow_rate_limit_limit", float64(e.rateLimits[name].Limit), "resource", name)
+	}
+	m.family("ghflow_rate_limit_reset_timestamp_seconds", "gauge", "When the rate limit window resets.")
+	for _, name := range resources {
+		m.sample("ghflow_rate_limit_reset_timestamp_seconds", float64(e.rateLimits[name].Reset), "resource", name)
+	}
+}
+
+// writeLatest writes gauges about the latest run of each workflow.
+func (e *Exporter) writeLatest(m *writer) {
+	type latest struct {
+		repo string
+		run  github.WorkflowRun
+	}
+	var runs []latest
+	for _, r := range e.results {
+		seen := map[string]bool{}
+		var repoRuns []latest
+		for _, run := range r.Runs {
+			if seen[run.WorkflowName] {
+				continue
+			}
+			seen[run.WorkflowName] = true
+			repoRuns = append(repoRuns, latest{r.Repo.FullName(), run})
+		}
+		sort.Slice(repoRuns, func(i, j int) bool {
+			return repoRuns[i].run.WorkflowName < repoRuns[j].run.WorkflowName
+		})
+		runs = append(runs, repoRuns...)
+	}
+
+	m.family("ghflow_workflow_status", "gauge", "Status of the workflow's latest run: 1 for the current status, 0 for the others.")
+	for _, l := range runs {
+		current := l.run.RunStatus()
+		for _, s := range statuses {
+			m.sample("ghflow_workflow_status", bool01(s == current), "repo", l.repo, "workflow", l.run.WorkflowName, "status", string(s))
+		}
+	}
+	m.family("ghflow_workflow_last_run_number", "gauge", "Run number of the workflow's latest run.")
+	for _, l := range runs {
+		m.sample("ghflow_workflow_last_run_number", float64(l.run.RunNumber), "repo", l.repo, "workflow", l.run.WorkflowName)
+	}
+	m.family("ghflow_workflow_last_run_timestamp_seconds", "gauge", "When the workflow's latest run was created.")
+	for _, l := range runs {
+		m.sample("ghflow_workflow_last_run_timestamp_seconds", float64(l.run.CreatedAt.Unix()), "repo", l.repo, "workflow", l.run.WorkflowName)
+	}
+	m.family("ghflow_workflow_last_run_duration_seconds", "gauge", "How long the workflow's latest run took, or has taken so far.")
+	for _, l := range runs {
+		m.sample("ghflow_workflow_last_run_duration_seconds", stats.RunDuration(l.run).Seconds(), "repo", l.repo, "workflow", l.run.WorkflowName)
+	}
+	m.family("ghflow_workflow_last_run_queue_seconds", "gauge", "How long the workflow's latest run waited before starting.")
+	for _, l := range runs {
+		m.sample("ghflow_workflow_last_run_queue_seconds", stats.QueueTime(l.run).Seconds(), "repo", l.repo, "workflow", l.run.WorkflowName)
+	}
+}
+
+// writeTotals writes the counters of completed runs.
+func (e *Exporter) writeTotals(m *writer) {
+	keys := make([]workflowKey, 0, len(e.totals))
+	for k := range e.totals {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].repo != keys[j].repo {
+			return keys[i].repo < keys[j].repo
+		}
+		return keys[i].workflow < keys[j].workflow
+	})
+
+	m.family("ghflow_runs_total", "counter", "Completed runs seen in the latest runs fetched per repo each poll, by conclusion.")
+	for _, k := range keys {
+		t := e.totals[k]
+		conclusions := make([]string, 0, len(t.conclusions))
+		for c := range t.conclusions {
+			conclusions = append(conclusions, c)
+		}
+		sort.Strings(conclusions)
+		for _, c := range conclusions {
+			m.sample("ghflow_runs_total", float64(t.conclusions[c]), "repo", k.repo, "workflow", k.workflow, "conclusion", c)
+		}
+	}
+	m.family("ghflow_run_failures_total", "counter", "Completed runs seen in the latest runs fetched per repo each poll that failed or timed out.")
+	for _, k := range keys {
+		t := e.totals[k]
+		failures := t.conclusions["failure"] + t.conclusions["timed_out"] + t.conclusions["startup_failure"]
+		m.sample("ghflow_run_failures_total", float64(failures), "repo", k.repo, "workflow", k.workflow)
+	}
+	m.family("ghflow_run_duration_seconds", "summary", "Duration of completed runs.")
+	for _, k := range keys {
+		t := e.totals[k]
+		m.sample("ghflow_run_duration_seconds_sum", t.durationSum, "repo", k.repo, "workflow", k.workflow)
+		m.sample("ghflow_run_duration_seconds_count", float64(t.durationCount), "repo", k.repo, "workflow", k.workflow)
+	}
+	m.family("ghflow_run_queue_seconds", "summary", "Time completed runs waited before starting.")
+	for _, k := range keys {
+		t := e.totals[k]
+		m.sample("ghflow_run_queue_seconds_sum", t.queueSum, "repo", k.repo, "workflow", k.workflow)
+		m.sample("ghflow_run_queue_seconds_count", float64(t.queueCount), "repo", k.repo, "workflow", k.workflow)
+	}
+}
+
+type writer struct {
+	w io.Writer
+}
+
+func (m *writer) family(name, typ, help string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes one line; labels are name, value pairs.
+func (m *writer) sample(name string, value float64, labels ...string) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(labels[i])
+			b.WriteString(`="`)
+			b.WriteString(labelEscaper.Replace(labels[i+1]))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	b.WriteByte('\n')
+	io.WriteString(m.w, b.String())
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func bool01(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+func completed(id int64, conclusion string) github.WorkflowRun {
+	return github.WorkflowRun{ID: id, RunAttempt: 1, WorkflowName: "CI", Status: "completed", Conclusion: conclusion}
+}
+
+func TestCountSkipsBacklog(t *testing.T) {
+	e := NewExporter([]config.Repo{{Owner: "acme", Name: "app"}})
+
+	// Already completed when the exporter started
+	e.count("acme/app", []github.WorkflowRun{completed(1, "failure"), completed(2, "failure")})
+	if len(e.totals) != 0 {
+		t.Fatalf("first poll counted %v", e.totals)
+	}
+
+	running := completed(3, "")
+	running.Status = "in_progress"
+	e.count("acme/app", []github.WorkflowRun{running, completed(2, "failure"), completed(1, "failure")})
+	e.count("acme/app", []github.WorkflowRun{completed(3, "failure"), completed(2, "failure"), completed(1, "failure")})
+	e.count("acme/app", []github.WorkflowRun{completed(3, "failure"), completed(2, "failure")})
+
+	got := e.totals[workflowKey{"acme/app", "CI"}]
+	if got == nil || got.conclusions["failure"] != 1 {
+		t.Errorf("totals = %+v, want the one run that completed while polling", got)
+	}
+}
+
+func TestDuplicateRepos(t *testing.T) {
+	e := NewExporter([]config.Repo{
+		{Owner: "acme", Name: "app"},
+		{Owner: "Acme", Name: "App", Branch: "dev"},
+		{Owner: "acme", Name: "app", Workflow: "CI"},
+	})
+	if len(e.repos) != 1 || e.repos[0].Branch != "" || e.repos[0].Workflow != "" {
+		t.Fatalf("repos = %+v, want acme/app once, unfiltered", e.repos)
+	}
+
+	var out bytes.Buffer
+	e.Write(&out)
+	if n := strings.Count(out.String(), `ghflow_poll_errors_total{repo="acme/app"}`); n != 1 {
+		t.Errorf("poll errors series written %d times, want 1", n)
+	}
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// New returns the notifier for method: "bell", "osc9", "osc777" or
+// "notify-send". Terminal notifiers write to stderr, which is the same
+// terminal as the UI but doesn't race its frame writes on stdout.
+func New(method string) (Notifier, error) {
+	switch method {
+	case "bell":
+		return Bell{W: os.Stderr}, nil
+	case "osc9":
+		return OSC9{W: os.Stderr}, nil
+	case "osc777":
+		return OSC777{W: os.Stderr}, nil
+	case "notify-send":
+		return NotifySend{}, nil
+	default:
+		return nil, fmt.Errorf("unknown notify method %q: use bell, osc9, osc777 or notify-send", method)
+	}
+}
+
+// Bell rings the terminal bell. tmux turns it into a window alert.
+type Bell struct {
+	W io.Writer
+}
+
+func (b Bell) Notify(context.Context, Event) error {
+	_, err := io.WriteString(b.W, "\a")
+	return err
+}
+
+// OSC9 sends a desktop notification through the terminal (iTerm2,
+// Windows Terminal, kitty, foot, ...).
+type OSC9 struct {
+	W io.Writer
+}
+
+func (o OSC9) Notify(_ context.Context, e Event) error {
+	_, err := io.WriteString(o.W, passthrough("\x1b]9;"+clean(e.Title()+": "+e.Body())+"\a"))
+	return err
+}
+
+// OSC777 sends a desktop notification with a separate title and body
+// (urxvt, foot, WezTerm, VTE-based terminals).
+type OSC777 struct {
+	W io.Writer
+}
+
+func (o OSC777) Notify(_ context.Context, e Event) error {
+	seq := "\x1b]777;notify;" + strings.ReplaceAll(clean(e.Title()), ";", ",") + ";" + clean(e.Body()) + "\a"
+	_, err := io.WriteString(o.W, passthrough(seq))
+	return err
+}
+
+// NotifySend shows a desktop notification with libnotify's notify-send.
+type NotifySend struct{}
+
+// notifySendTimeout bounds a notify-send call that hangs on a missing
+// notification daemon.
+const notifySendTimeout = 5 * time.Second
+
+func (NotifySend) Notify(ctx context.Context, e Event) error {
+	ctx, cancel := context.WithTimeout(ctx, notifySendTimeout)
+	defer cancel()
+
+	urgency := "normal"
+	if e.Transition == Failed {
+		urgency = "critical"
+	}
+	cmd := exec.CommandContext(ctx, "notify-send", "--app-name=ghflow", "--urgency="+urgency, "--", e.Title(), e.Body()) // #nosec G204 -- fixed binary, arguments are passed directly without a shell
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify-send failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// clean drops control characters so names from the API can't end the
+// escape sequence early or inject their own.
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// passthrough wraps seq so tmux forwards it to the outer terminal
+// (needs "set -g allow-passthrough on" in tmux 3.3+).
+func passthrough(seq string) string {
+	if os.Getenv("TMUX") == "" {
+		return seq
+	}
+	return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+}
@@ -0,0 +1,259 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+// Transition is a change in a run's state worth telling someone about.
+type Transition string
+
+const (
+	Failed    Transition = "failed"    // A run finished red
+	Fixed     Transition = "fixed"     // A workflow went from red to green
+	Succeeded Transition = "succeeded" // A run finished green, without fixing anything
+	Started   Transition = "started"   // A queued run began running
+)
+
+// defaultTransitions notify when no rules are configured.
+var defaultTransitions = []Transition{Failed, Fixed}
+
+// anyTransitions are what a rule without transitions matches. Succeeded
+// fires on every green run, so it has to be asked for by name.
+var anyTransitions = []Transition{Failed, Fixed, Started}
+
+// Event is one transition of a run.
+type Event struct {
+	Repo       string
+	Run        github.WorkflowRun
+	Transition Transition
+}
+
+// Title is a short summary of the event.
+func (e Event) Title() string {
+	switch e.Transition {
+	case Failed:
+		return fmt.Sprintf("%s: %s failed", e.Repo, e.Run.WorkflowName)
+	case Fixed:
+		return fmt.Sprintf("%s: %s fixed", e.Repo, e.Run.WorkflowName)
+	case Succeeded:
+		return fmt.Sprintf("%s: %s succeeded", e.Repo, e.Run.WorkflowName)
+	default:
+		return fmt.Sprintf("%s: %s started", e.Repo, e.Run.WorkflowName)
+	}
+}
+
+// Body gives the run's details.
+func (e Event) Body() string {
+	body := fmt.Sprintf("#%d on %s", e.Run.RunNumber, e.Run.HeadBranch)
+	if e.Run.Actor.Login != "" {
+		body += " by " + e.Run.Actor.Login
+	}
+	return body
+}
+
+// Detect compares a repo's runs before and after a refresh. Both are
+// newest first. Nothing is reported without a previous state to compare
+// against.
+func Detect(repo string, prev, curr []github.WorkflowRun) []Event {
+	if len(prev) == 0 {
+		return nil
+	}
+	prevByID := make(map[int64]github.WorkflowRun, len(prev))
+	for _, run := range prev {
+		prevByID[run.ID] = run
+	}
+
+	var events []Event
+	for _, run := range curr {
+		was, seen := prevByID[run.ID]
+		if seen && was.Status == run.Status && was.Conclusion == run.Conclusion {
+			continue
+		}
+
+		var t Transition
+		switch {
+		case run.Status == "in_progress" && (!seen || was.Status == "queued"):
+			t = Started
+		case run.Status != "completed":
+			continue
+		case failed(run):
+			t = Failed
+		case run.Conclusion == "success" && lastFailed(prev, run):
+			t = Fixed
+		case run.Conclusion == "success":
+			t = Succeeded
+		default:
+			continue
+		}
+		events = append(events, Event{Repo: repo, Run: run, Transition: t})
+	}
+	return events
+}
+
+func failed(run github.WorkflowRun) bool {
+	switch run.Conclusion {
+	case "failure", "timed_out", "startup_failure":
+		return true
+	}
+	return false
+}
+
+// lastFailed reports whether the last completed run in prev of the same
+// workflow and branch as run (run itself included, for re-runs) failed.
+func lastFailed(prev []github.WorkflowRun, run github.WorkflowRun) bool {
+	for _, p := range prev {
+		if p.WorkflowName != run.WorkflowName || p.HeadBranch != run.HeadBranch {
+			continue
+		}
+		if p.Status != "completed" || (p.Conclusion != "success" && !failed(p)) {
+			continue
+		}
+		return failed(p)
+	}
+	return false
+}
+
+// Notifier delivers a notification.
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+// target is a notifier and the rules that pick its events.
+type target struct {
+	notifier    Notifier
+	rules       []config.NotifyRule
+	skipOwnRuns bool
+}
+
+// Dispatcher filters events through the configured rules and passes
+// the rest to each notifier.
+type Dispatcher struct {
+	targets []target
+
+	viewerMu sync.Mutex
+	viewer   string
+}
+
+// NewDispatcher builds the notifier named by cfg.Method and one per
+// configured webhook.
+func NewDispatcher(cfg config.NotifyConfig) (*Dispatcher, error) {
+	d := &Dispatcher{}
+	if cfg.Method != "" {
+		n, err := New(cfg.Method)
+		if err != nil {
+			return nil, err
+		}
+		d.targets = append(d.targets, target{notifier: n, rules: cfg.Rules, skipOwnRuns: cfg.SkipOwnRuns})
+	}
+	for _, wh := range cfg.Webhooks {
+		n, err := NewWebhook(wh)
+		if err != nil {
+			return nil, err
+		}
+		d.targets = append(d.targets, target{notifier: n, rules: wh.Rules})
+	}
+	if len(d.targets) == 0 {
+		return nil, fmt.Errorf("notify: set a method or at least one webhook")
+	}
+
+	for _, t := range d.targets {
+		if err := checkRules(t.rules); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func checkRules(rules []config.NotifyRule) error {
+	for _, rule := range rules {
+		for _, t := range rule.Transitions {
+			switch Transition(t) {
+			case Failed, Fixed, Succeeded, Started:
+			default:
+				return fmt.Errorf("unknown notify transition %q: use failed, fixed, succeeded or started", t)
+			}
+		}
+	}
+	return nil
+}
+
+// Send notifies about every event the rules allow. Targets are notified
+// concurrently, so a slow one doesn't hold up the rest.
+func (d *Dispatcher) Send(ctx context.Context, events []Event) error {
+	var mu sync.Mutex
+	var errs []string
+	var wg sync.WaitGroup
+	for _, t := range d.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, e := range events {
+				if !allowed(t.rules, e) || (t.skipOwnRuns && d.ownRun(ctx, e)) {
+					continue
+				}
+				if err := t.notifier.Notify(ctx, e); err != nil {
+					mu.Lock()
+					errs = append(errs, err.Error())
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return fmt.Errorf("notify: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func allowed(rules []config.NotifyRule, e Event) bool {
+	if len(rules) == 0 {
+		return slices.Contains(defaultTransitions, e.Transition)
+	}
+	for _, rule := range rules {
+		if rule.Repo != "" && !strings.EqualFold(rule.Repo, e.Repo) {
+			continue
+		}
+		if rule.Branch != "" && rule.Branch != e.Run.HeadBranch {
+			continue
+		}
+		if len(rule.Transitions) == 0 {
+			if slices.Contains(anyTransitions, e.Transition) {
+				return true
+			}
+			continue
+		}
+		for _, t := range rule.Transitions {
+			if Transition(t) == e.Transition {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ownRun reports whether the gh user triggered the event's run. The
+// login is looked up until a lookup succeeds; while it can't be, no run
+// counts as the user's own.
+func (d *Dispatcher) ownRun(ctx context.Context, e Event) bool {
+	if e.Run.Actor.Login == "" {
+		return false
+	}
+	d.viewerMu.Lock()
+	defer d.viewerMu.Unlock()
+	if d.viewer == "" {
+		login, err := github.FetchViewerLogin(ctx)
+		if err != nil {
+			return false
+		}
+		d.viewer = login
+	}
+	return strings.EqualFold(d.viewer, e.Run.Actor.Login)
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+func TestAllowed(t *testing.T) {
+	run := github.WorkflowRun{ID: 1, HeadBranch: "main", WorkflowName: "CI"}
+	event := func(tr Transition) Event {
+		return Event{Repo: "acme/app", Run: run, Transition: tr}
+	}
+
+	tests := []struct {
+		name  string
+		rules []config.NotifyRule
+		want  map[Transition]bool
+	}{
+		{
+			name: "no rules",
+			want: map[Transition]bool{Failed: true, Fixed: true},
+		},
+		{
+			name:  "rule without transitions",
+			rules: []config.NotifyRule{{Repo: "Acme/App"}},
+			want:  map[Transition]bool{Failed: true, Fixed: true, Started: true},
+		},
+		{
+			name:  "succeeded by name",
+			rules: []config.NotifyRule{{Transitions: []string{"succeeded"}}},
+			want:  map[Transition]bool{Succeeded: true},
+		},
+		{
+			name:  "other branch",
+			rules: []config.NotifyRule{{Branch: "dev"}},
+			want:  map[Transition]bool{},
+		},
+	}
+	for _, tt := range tests {
+		for _, tr := range []Transition{Failed, Fixed, Succeeded, Started} {
+			if got := allowed(tt.rules, event(tr)); got != tt.want[tr] {
+				t.Errorf("%s: allowed(%s) = %v, want %v", tt.name, tr, got, tt.want[tr])
+			}
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+)
+
+// Payload templates for the built-in presets. Templates see a
+// WebhookData; the json function quotes a value for JSON.
+var presets = map[string]string{
+	"slack":   `{"text": {{json .Text}}}`,
+	"discord": `{"content": {{json .Text}}, "username": "ghflow"}`,
+	"json": `{"repo": {{json .Repo}}, "workflow": {{json .Workflow}}, "branch": {{json .Branch}},` +
+		` "run_number": {{.RunNumber}}, "status": {{json .Status}}, "conclusion": {{json .Conclusion}},` +
+		` "transition": {{json .Transition}}, "actor": {{json .Actor}}, "url": {{json .URL}}}`,
+}
+
+// WebhookData is what payload templates are executed with.
+type WebhookData struct {
+	Repo       string
+	Workflow   string
+	Branch     string
+	RunNumber  int
+	RunAttempt int
+	Status     string
+	Conclusion string
+	Transition string
+	Actor      string
+	URL        string
+	Title      string
+	Body       string
+	Text       string // Title, body and URL in one line, for chat presets
+}
+
+func newWebhookData(e Event) WebhookData {
+	text := e.Title() + " (" + e.Body() + ")"
+	if e.Run.HTMLURL != "" {
+		text += " " + e.Run.HTMLURL
+	}
+	return WebhookData{
+		Repo:       e.Repo,
+		Workflow:   e.Run.WorkflowName,
+		Branch:     e.Run.HeadBranch,
+		RunNumber:  e.Run.RunNumber,
+		RunAttempt: e.Run.RunAttempt,
+		Status:     e.Run.Status,
+		Conclusion: e.Run.Conclusion,
+		Transition: string(e.Transition),
+		Actor:      e.Run.Actor.Login,
+		URL:        e.Run.HTMLURL,
+		Title:      e.Title(),
+		Body:       e.Body(),
+		Text:       text,
+	}
+}
+
+const (
+	webhookTimeout  = 10 * time.Second
+	webhookAttempts = 4
+
+	// dedupeWindow is how long a delivered event is remembered.
+	dedupeWindow = 24 * time.Hour
+)
+
+var (
+	webhookBackoff = time.Second
+
+	// maxRetryWait caps how long a Retry-After header can hold up a
+	// delivery, and with it the poll that triggered it.
+	maxRetryWait = 30 * time.Second
+)
+
+// Webhook POSTs a templated JSON payload to a URL. Failed deliveries are
+// retried with backoff, and an event is delivered at most once.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+
+	tmpl *template.Template
+
+	mu   sync.Mutex
+	sent map[string]time.Time // dedupe key -> delivered at
+}
+
+// NewWebhook builds a webhook notifier from cfg. Template, when set,
+// overrides Preset; the default preset is "json".
+func NewWebhook(cfg config.WebhookConfig) (*Webhook, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("notify webhook: url is required")
+	}
+	text := cfg.Template
+	if text == "" {
+		preset := cfg.Preset
+		if preset == "" {
+			preset = "json"
+		}
+		var ok bool
+		if text, ok = presets[preset]; !ok {
+			return nil, fmt.Errorf("unknown webhook preset %q: use slack, discord or json", preset)
+		}
+	}
+
+	tmpl, err := template.New("payload").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("notify webhook template: %w", err)
+	}
+
+	return &Webhook{
+		URL:    cfg.URL,
+		Client: &http.Client{Timeout: webhookTimeout},
+		tmpl:   tmpl,
+		sent:   map[string]time.Time{},
+	}, nil
+}
+
+func dedupeKey(e Event) string {
+	return fmt.Sprintf("%s#%d.%d:%s", e.Repo, e.Run.ID, e.Run.RunAttempt, e.Transition)
+}
+
+func (w *Webhook) Notify(ctx context.Context, e Event) error {
+	key := dedupeKey(e)
+	w.mu.Lock()
+	now := time.Now()
+	for k, at := range w.sent {
+		if now.Sub(at) > dedupeWindow {
+			delete(w.sent, k)
+		}
+	}
+	if _, done := w.sent[key]; done {
+		w.mu.Unlock()
+		return nil
+	}
+	// Claim the event up front so a concurrent Send doesn't post it too
+	w.sent[key] = now
+	w.mu.Unlock()
+
+	err := w.deliver(ctx, e)
+	if err != nil {
+		w.mu.Lock()
+		delete(w.sent, key)
+		w.mu.Unlock()
+	}
+	return err
+}
+
+func (w *Webhook) deliver(ctx context.Context, e Event) error {
+	var payload bytes.Buffer
+	if err := w.tmpl.Execute(&payload, newWebhookData(e)); err != nil {
+		return fmt.Errorf("notify webhook template: %w", err)
+	}
+	if !json.Valid(payload.Bytes()) {
+		return fmt.Errorf("notify webhook template did not produce valid JSON")
+	}
+
+	backoff := webhookBackoff
+	var lastErr error
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		retryAfter, err := w.post(ctx, payload.Bytes())
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if retryAfter < 0 || attempt == webhookAttempts {
+			break
+		}
+
+		wait := min(max(backoff, retryAfter), maxRetryWait)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+	return lastErr
+}
+
+// post sends one request. retryAfter is negative when retrying can't
+// help, and otherwise the delay the server asked for (possibly zero).
+func (w *Webhook) post(ctx context.Context, payload []byte) (retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ghflow")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return -1, ctx.Err()
+		}
+		return 0, fmt.Errorf("notify webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+		return retryAfter, fmt.Errorf("notify webhook: %s", resp.Status)
+	default:
+		return -1, fmt.Errorf("notify webhook: %s", resp.Status)
+	}
+}
@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+// fastRetries shortens the retry delays for the duration of a test.
+func fastRetries(t *testing.T) {
+	t.Helper()
+	backoff, maxWait := webhookBackoff, maxRetryWait
+	webhookBackoff, maxRetryWait = time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { webhookBackoff, maxRetryWait = backoff, maxWait })
+}
+
+func newTestWebhook(t *testing.T, url string) *Webhook {
+	t.Helper()
+	w, err := NewWebhook(config.WebhookConfig{URL: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func testEvent(attempt int) Event {
+	run := github.WorkflowRun{ID: 7, RunNumber: 3, RunAttempt: attempt, WorkflowName: "CI", Status: "completed", Conclusion: "failure"}
+	return Event{Repo: "acme/app", Run: run, Transition: Failed}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	fastRetries(t)
+
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch posts.Add(1) {
+		case 1:
+			// Far longer than the test would wait without the cap
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := newTestWebhook(t, srv.URL).Notify(ctx, testEvent(1)); err != nil {
+		t.Fatal(err)
+	}
+	if n := posts.Load(); n != 3 {
+		t.Errorf("posted %d times, want 3", n)
+	}
+}
+
+func TestWebhookNoRetry(t *testing.T) {
+	fastRetries(t)
+
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	if err := newTestWebhook(t, srv.URL).Notify(context.Background(), testEvent(1)); err == nil {
+		t.Error("want an error for a 400")
+	}
+	if n := posts.Load(); n != 1 {
+		t.Errorf("posted %d times, want 1", n)
+	}
+}
+
+func TestWebhookDedupe(t *testing.T) {
+	fastRetries(t)
+
+	var posts atomic.Int32
+	var failing atomic.Bool
+	failing.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	hook := newTestWebhook(t, srv.URL)
+
+	// A failed delivery isn't remembered, so the next poll tries again
+	if err := hook.Notify(ctx, testEvent(1)); err == nil {
+		t.Fatal("want an error while the endpoint fails")
+	}
+	failing.Store(false)
+	for range 2 {
+		if err := hook.Notify(ctx, testEvent(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := posts.Load(); n != 2 {
+		t.Errorf("posted %d times, want 2", n)
+	}
+
+	// A re-run is a new event
+	if err := hook.Notify(ctx, testEvent(2)); err != nil {
+		t.Fatal(err)
+	}
+	if n := posts.Load(); n != 3 {
+		t.Errorf("posted %d times after a re-run, want 3", n)
+	}
+}
+
+func TestSendConcurrent(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	fast := make(chan struct{}, 1)
+	quick := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fast <- struct{}{}
+	}))
+	defer quick.Close()
+
+	d, err := NewDispatcher(config.NotifyConfig{Webhooks: []config.WebhookConfig{{URL: slow.URL}, {URL: quick.URL}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- d.Send(context.Background(), []Event{testEvent(1)}) }()
+
+	select {
+	case <-fast:
+	case <-time.After(5 * time.Second):
+		t.Error("the second webhook waited for the first")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,242 @@
+package poll
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/history"
+)
+
+const (
+	// RunsPerRepo is how many recent runs are kept for each repo.
+	RunsPerRepo = 20
+
+	// FilteredFetchSize is how many runs a repo with a workflow filter
+	// fetches before filtering, so it still ends up with a useful number.
+	FilteredFetchSize = 100
+
+	// maxConcurrent caps in-flight gh api processes.
+	maxConcurrent = 4
+)
+
+// Requests is shared by everything in the process that calls the API, so
+// they stay under one concurrency limit and identical requests are only
+// made once.
+var Requests = github.NewScheduler(maxConcurrent)
+
+// Result is the latest runs of one repo, filtered like a dashboard card.
+type Result struct {
+	Repo config.Repo
+	Runs []github.WorkflowRun
+	Err  error
+	Gen  uint64 // Requests sequence number of the request that fetched Runs
+}
+
+// Status is the status of the latest run, or unknown without runs.
+func (r Result) Status() github.RunStatus {
+	if len(r.Runs) == 0 {
+		return github.StatusUnknown
+	}
+	return r.Runs[0].RunStatus()
+}
+
+// FetchLimit is how many runs to request for repo before filtering.
+func FetchLimit(repo config.Repo) int {
+	if repo.Workflow != "" {
+		return FilteredFetchSize
+	}
+	return RunsPerRepo
+}
+
+// FilterRuns applies repo's branch and workflow filter and keeps at most
+// RunsPerRepo runs.
+func FilterRuns(repo config.Repo, runs []github.WorkflowRun) []github.WorkflowRun {
+	if repo.Branch == "" && repo.Workflow == "" && len(runs) <= RunsPerRepo {
+		return runs
+	}
+	filtered := make([]github.WorkflowRun, 0, len(runs))
+	for _, run := range runs {
+		if !repo.MatchesRun(run.HeadBranch, run.WorkflowName) {
+			continue
+		}
+		filtered = append(filtered, run)
+		if len(filtered) == RunsPerRepo {
+			break
+		}
+	}
+	return filtered
+}
+
+// FetchRepo fetches repo's runs with the REST API.
+func FetchRepo(ctx context.Context, repo config.Repo) ([]github.WorkflowRun, error) {
+	runs, _, err := fetchRepo(ctx, repo)
+	return runs, err
+}
+
+func fetchRepo(ctx context.Context, repo config.Repo) ([]github.WorkflowRun, uint64, error) {
+	branch := restBranch(repo)
+	key := "runs:" + repo.FullName() + "?branch=" + branch + "&limit=" + fmt.Sprint(FetchLimit(repo))
+	val, gen, err := Requests.Do(ctx, key, func(ctx context.Context) (any, error) {
+		filter := github.RunFilter{Branch: branch}
+		return github.FetchRuns(ctx, repo.Owner, repo.Name, filter, FetchLimit(repo))
+	})
+	if err != nil {
+		return nil, gen, err
+	}
+	return FilterRuns(repo, val.([]github.WorkflowRun)), gen, nil
+}
+
+// defaultBranches remembers each repo's default branch once a batch has
+// reported it, so repos filtered to another branch stop being batched.
+var defaultBranches sync.Map // github.RepoRef -> string
+
+// batchable reports whether the GraphQL batch returns the same runs for
+// repo as FetchRepo. The batch only sees the default branch, so it suits
+// cards without a filter, which show that branch, and cards filtered to
+// it, but none with a workflow filter, which need more runs than it
+// fetches.
+func batchable(repo config.Repo) bool {
+	if repo.Workflow != "" {
+		return false
+	}
+	if repo.Branch == "" {
+		return true
+	}
+	branch, ok := defaultBranches.Load(github.RepoRef{Owner: repo.Owner, Name: repo.Name})
+	return !ok || branch == repo.Branch
+}
+
+// restBranch is the branch FetchRepo asks for. A card without a filter
+// shows the default branch, as in the batch, once a batch has named it;
+// until then it gets every branch.
+func restBranch(repo config.Repo) string {
+	if repo.Branch != "" || repo.Workflow != "" {
+		return repo.Branch
+	}
+	if branch, ok := defaultBranches.Load(github.RepoRef{Owner: repo.Owner, Name: repo.Name}); ok {
+		return branch.(string)
+	}
+	return ""
+}
+
+// FetchAll fetches every repo, results in the order of repos. Repos
+// without a filter or filtered to their default branch share one GraphQL
+// request; the rest, and any the batch misses, are fetched one by one
+// with REST.
+func FetchAll(ctx context.Context, repos []config.Repo) []Result {
+	results := make([]Result, len(repos))
+	var refs []github.RepoRef
+	var names []string
+	var batched []int
+	for i, r := range repos {
+		results[i].Repo = r
+		if batchable(r) {
+			refs = append(refs, github.RepoRef{Owner: r.Owner, Name: r.Name})
+			names = append(names, r.FullName())
+			batched = append(batched, i)
+		}
+	}
+
+	done := make([]bool, len(repos))
+	if len(refs) > 0 {
+		val, gen, err := Requests.Do(ctx, "batch:"+strings.Join(names, ","), func(ctx context.Context) (any, error) {
+			return github.FetchLatestRunsBatch(ctx, refs, RunsPerRepo)
+		})
+		if err == nil {
+			batch := val.(map[github.RepoRef]github.BranchRuns)
+			for j, ref := range refs {
+				got, ok := batch[ref]
+				if !ok {
+					continue
+				}
+				defaultBranches.Store(ref, got.Branch)
+				i := batched[j]
+				if repos[i].Branch != "" && got.Branch != repos[i].Branch {
+					continue
+				}
+				results[i].Runs = FilterRuns(repos[i], got.Runs)
+				results[i].Gen = gen
+				done[i] = true
+			}
+		}
+	}
+
+	// Requests limits how many of these run at once
+	var wg sync.WaitGroup
+	for i := range repos {
+		if done[i] {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i].Runs, results[i].Gen, results[i].Err = fetchRepo(ctx, repos[i])
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// RunJobs fetches the jobs of run's latest attempt, and, for a re-run
+// workflow, those of the earlier attempts. An earlier attempt that can't
+// be fetched is left out; flaky detection works with whatever it gets.
+func RunJobs(ctx context.Context, repo config.Repo, run github.WorkflowRun) (latest, earlier []github.Job, err error) {
+	latest, err = github.FetchRunJobs(ctx, repo.Owner, repo.Name, run.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for attempt := 1; attempt < run.RunAttempt; attempt++ {
+		jobs, err := github.FetchAttemptJobs(ctx, repo.Owner, repo.Name, run.ID, attempt)
+		if err != nil {
+			continue
+		}
+		earlier = append(earlier, jobs...)
+	}
+	return latest, earlier, nil
+}
+
+// jobsFetched holds the run attempts RecordJobs has fetched, so one
+// without any jobs isn't asked for again on every poll.
+var jobsFetched sync.Map // string -> bool
+
+// RecordJobs writes the jobs of runs that failed or were re-run to store,
+// so flaky jobs turn up without anyone opening the run. Runs whose jobs
+// are on record already are skipped.
+func RecordJobs(ctx context.Context, store *history.Store, repo config.Repo, runs []github.WorkflowRun) error {
+	var want []github.WorkflowRun
+	for _, run := range runs {
+		if run.Status == "completed" && (run.RunStatus() == github.StatusFailure || run.RunAttempt > 1) {
+			want = append(want, run)
+		}
+	}
+	if len(want) == 0 {
+		return nil
+	}
+	want, err := store.WithoutJobs(repo.FullName(), want)
+	if err != nil {
+		return err
+	}
+
+	for _, run := range want {
+		key := fmt.Sprintf("%s:%d:%d", repo.FullName(), run.ID, run.RunAttempt)
+		if _, ok := jobsFetched.Load(key); ok {
+			continue
+		}
+		val, _, err := Requests.Do(ctx, "record-jobs:"+key, func(ctx context.Context) (any, error) {
+			latest, earlier, err := RunJobs(ctx, repo, run)
+			return append(latest, earlier...), err
+		})
+		if err != nil {
+			return err
+		}
+		jobsFetched.Store(key, true)
+		if err := store.RecordJobs(repo.FullName(), val.([]github.Job)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package poll
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+)
+
+// fakeGH puts a gh on PATH that logs the endpoint of each call to the
+// returned file, answers GraphQL with graphql and REST with no runs.
+func fakeGH(t *testing.T, graphql string) string {
+	t.Helper()
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$1 $2\" >> " + log + "\n" +
+		"case \"$2\" in\ngraphql) cat <<'EOF'\n" + graphql + "\nEOF\n;;\n" +
+		"*) echo '{\"total_count\":0,\"workflow_runs\":[]}';;\nesac\n"
+	if err := os.WriteFile(filepath.Join(dir, "gh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return log
+}
+
+func calls(t *testing.T, log string) []string {
+	t.Helper()
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+const batchResponse = `{"data": {
+  "r0": {"defaultBranchRef": {"name": "main", "target": {"history": {"nodes": [{"oid": "a", "checkSuites": {"nodes": [
+    {"status": "COMPLETED", "conclusion": "SUCCESS", "branch": {"name": "main"},
+     "workflowRun": {"databaseId": 1, "runNumber": 1, "createdAt": "2026-10-18T10:00:00Z", "updatedAt": "2026-10-18T10:05:00Z", "workflow": {"name": "CI"}}}
+  ]}}]}}}},
+  "r1": {"defaultBranchRef": {"name": "trunk", "target": {"history": {"nodes": []}}}},
+  "r2": {"defaultBranchRef": {"name": "main", "target": {"history": {"nodes": []}}}}
+}}`
+
+func TestFetchAllBatchesDefaultConfig(t *testing.T) {
+	log := fakeGH(t, batchResponse)
+	repos := []config.Repo{
+		{Owner: "acme", Name: "app"},
+		{Owner: "acme", Name: "lib"},
+		{Owner: "acme", Name: "web"},
+	}
+
+	results := FetchAll(context.Background(), repos)
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.Repo.FullName(), r.Err)
+		}
+	}
+	if got := calls(t, log); len(got) != 1 || !strings.HasPrefix(got[0], "api graphql") {
+		t.Fatalf("gh calls = %q, want one GraphQL request", got)
+	}
+	if len(results[0].Runs) != 1 || results[0].Runs[0].ID != 1 {
+		t.Errorf("acme/app runs = %v, want run 1", results[0].Runs)
+	}
+
+	// Fetched on its own, an unfiltered card still shows the default
+	// branch; a card filtered to another branch is never batched
+	if _, err := FetchRepo(context.Background(), repos[1]); err != nil {
+		t.Fatal(err)
+	}
+	FetchAll(context.Background(), []config.Repo{{Owner: "acme", Name: "lib", Branch: "dev"}})
+	got := calls(t, log)
+	if len(got) != 3 || !strings.Contains(got[1], "repos/acme/lib/actions/runs?branch=trunk&") ||
+		!strings.Contains(got[2], "repos/acme/lib/actions/runs?branch=dev&") {
+		t.Errorf("gh calls = %q, want REST for trunk, then dev", got)
+	}
+}
@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -52,6 +53,113 @@ func GetRepoInfo(path string) (*RepoInfo, error) {
 	}, nil
 }
 
+// DefaultScanDepth is how many directories deep Scan looks by default.
+const DefaultScanDepth = 3
+
+// scanSkip are directories full of dependencies or build output, which
+// hold vendored checkouts rather than the user's own. A directory by one
+// of these names that is itself a checkout is still listed.
+var scanSkip = map[string]bool{
+	"node_modules":     true,
+	"vendor":           true,
+	"bower_components": true,
+	"target":           true,
+	"dist":             true,
+	"build":            true,
+	"venv":             true,
+	"__pycache__":      true,
+	"Pods":             true,
+}
+
+// Scan finds the GitHub repos checked out under root, at most depth
+// directories down. It doesn't look inside a repo once found, nor in
+// hidden or dependency directories that aren't repos. Each repo is
+// listed once, at the first checkout found.
+func Scan(root string, depth int) ([]RepoInfo, error) {
+	root = filepath.Clean(root)
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	var repos []RepoInfo
+	seen := map[string]bool{}
+	err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped, not fatal
+			if path == root {
+				return err
+			}
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if IsGitRepo(path) {
+			if info, err := GetRepoInfo(path); err == nil && info != nil {
+				key := strings.ToLower(info.Owner + "/" + info.Name)
+				if !seen[key] {
+					seen[key] = true
+					repos = append(repos, *info)
+				}
+			}
+			return filepath.SkipDir
+		}
+		if path != root && (strings.HasPrefix(d.Name(), ".") || scanSkip[d.Name()]) {
+			return filepath.SkipDir
+		}
+		if rel, err := filepath.Rel(root, path); err == nil && rel != "." && strings.Count(rel, string(filepath.Separator))+1 >= depth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return repos, err
+}
+
+// validNamePart matches a GitHub owner or repo name.
+var validNamePart = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ParseRef parses a repo given by name rather than by checkout: owner/name,
+// or a GitHub URL such as https://github.com/owner/name, which may point
+// anywhere inside the repo.
+func ParseRef(s string) (owner, name string, ok bool) {
+	s = strings.TrimSpace(s)
+	if owner, name := parseGitHubURL(s); owner != "" {
+		return checkRef(owner, name)
+	}
+
+	for _, scheme := range []string{"https://", "http://"} {
+		s = strings.TrimPrefix(s, scheme)
+	}
+	s = strings.TrimPrefix(s, "www.")
+	rest, isURL := strings.CutPrefix(s, "github.com/")
+	if isURL {
+		s = rest
+	}
+	parts := strings.Split(strings.TrimSuffix(s, "/"), "/")
+	if isURL && len(parts) > 2 {
+		parts = parts[:2] // e.g. /tree/main or /actions
+	}
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	owner, name = parts[0], strings.TrimSuffix(parts[1], ".git")
+	return checkRef(owner, name)
+}
+
+// checkRef returns owner and name if they could name a GitHub repo.
+func checkRef(owner, name string) (string, string, bool) {
+	for _, part := range []string{owner, name} {
+		if !validNamePart.MatchString(part) || part == "." || part == ".." {
+			return "", "", false
+		}
+	}
+	return owner, name, true
+}
+
 func parseGitHubURL(url string) (owner, name string) {
 	// SSH format: git@github.com:owner/repo.git
 	sshPattern := regexp.MustCompile(`git@github\.com:([^/]+)/([^/]+?)(?:\.git)?$`)
@@ -99,3 +207,23 @@ func ListAllEntries(path string) ([]os.DirEntry, error) {
 
 	return result, nil
 }
+
+// TopLevel returns the root of the git work tree containing path.
+func TopLevel(path string) (string, error) {
+	cmd := exec.Command("git", "-C", path, "rev-parse", "--show-toplevel") // #nosec G204 -- fixed binary, path is passed as an argument (no shell)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not inside a git repository", path)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// HeadSHA returns the commit checked out at path.
+func HeadSHA(path string) (string, error) {
+	cmd := exec.Command("git", "-C", path, "rev-parse", "HEAD") // #nosec G204 -- fixed binary, path is passed as an argument (no shell)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("reading HEAD of %s: %w", path, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// checkout makes a git repo at root/rel with origin set to remote.
+func checkout(t *testing.T, root, rel, remote string) {
+	t.Helper()
+	dir := filepath.Join(root, rel)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"remote", "add", "origin", remote}} {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	checkout(t, root, "app", "git@github.com:acme/app.git")
+	checkout(t, root, "app/nested", "https://github.com/acme/nested") // Inside a repo
+	checkout(t, root, "build", "https://github.com/acme/build.git")   // Named like build output
+	checkout(t, root, "copy", "https://github.com/Acme/App")          // Same repo again
+	checkout(t, root, "gitlab", "https://gitlab.com/acme/other.git")  // Not on GitHub
+	checkout(t, root, "node_modules/dep", "https://github.com/acme/dep")
+	checkout(t, root, ".cache/hidden", "https://github.com/acme/hidden")
+	checkout(t, root, "work/go/lib", "https://github.com/acme/lib")
+	checkout(t, root, "work/go/old/tool", "https://github.com/acme/tool")
+
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{1, []string{"acme/app", "acme/build"}},
+		{3, []string{"acme/app", "acme/build", "acme/lib"}},
+		{4, []string{"acme/app", "acme/build", "acme/lib", "acme/tool"}},
+	}
+	for _, tt := range tests {
+		repos, err := Scan(root, tt.depth)
+		if err != nil {
+			t.Fatalf("Scan(%d): %v", tt.depth, err)
+		}
+		var got []string
+		for _, r := range repos {
+			got = append(got, r.Owner+"/"+r.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Scan(%d) = %v, want %v", tt.depth, got, tt.want)
+		}
+	}
+
+	if _, err := Scan(filepath.Join(root, "missing"), DefaultScanDepth); err == nil {
+		t.Error("Scan(missing) succeeded, want an error")
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		in          string
+		owner, name string
+		ok          bool
+	}{
+		{"acme/app", "acme", "app", true},
+		{"  acme/app\n", "acme", "app", true},
+		{"acme/app.git", "acme", "app", true},
+		{"acme/app/", "acme", "app", true},
+		{"acme/app.js", "acme", "app.js", true},
+		{"https://github.com/acme/app", "acme", "app", true},
+		{"https://github.com/acme/app.git", "acme", "app", true},
+		{"http://github.com/acme/app/", "acme", "app", true},
+		{"github.com/acme/app", "acme", "app", true},
+		{"https://github.com/acme/app/tree/main/cmd", "acme", "app", true},
+		{"https://github.com/acme/app/actions/runs/42", "acme", "app", true},
+		{"https://www.github.com/acme/app", "acme", "app", true},
+		{"www.github.com/acme/app.git", "acme", "app", true},
+		{"git@github.com:acme/app.git", "acme", "app", true},
+		{"", "", "", false},
+		{"acme", "", "", false},
+		{"acme/app/extra", "", "", false},
+		{"acme/..", "", "", false},
+		{"../app", "", "", false},
+		{"https://github.com/../app", "", "", false},
+		{"https://github.com/acme/..", "", "", false},
+		{"git@github.com:acme/..", "", "", false},
+		{"acme/my app", "", "", false},
+		{"https://github.com/acme", "", "", false},
+		{"https://gitlab.com/acme/app", "", "", false},
+	}
+	for _, tt := range tests {
+		owner, name, ok := ParseRef(tt.in)
+		if owner != tt.owner || name != tt.name || ok != tt.ok {
+			t.Errorf("ParseRef(%q) = %q, %q, %v, want %q, %q, %v", tt.in, owner, name, ok, tt.owner, tt.name, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package report
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func writeMarkdown(w io.Writer, r Report) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "# %s\n\n", mdText(r.Title))
+
+	if len(r.Repos) == 0 {
+		fmt.Fprintln(b, "No repos.")
+		return b.Flush()
+	}
+
+	fmt.Fprintln(b, "| Repo | Status | Success rate | Latest run |")
+	fmt.Fprintln(b, "|------|--------|--------------|------------|")
+	for _, repo := range r.Repos {
+		latest := ""
+		if len(repo.Runs) > 0 {
+			run := repo.Runs[0]
+			latest = fmt.Sprintf("%s #%d", run.WorkflowName, run.RunNumber)
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", mdCell(repo.Name), repo.Status, successRate(repo.Stats), mdCell(latest))
+	}
+
+	for _, repo := range r.Repos {
+		fmt.Fprintf(b, "\n## %s\n\n", mdText(repo.Name))
+		fmt.Fprintf(b, "- Status: **%s**\n", repo.Status)
+		if f := filters(repo); f != "" {
+			fmt.Fprintf(b, "- Filter: %s\n", mdText(f))
+		}
+		if repo.Error != "" {
+			fmt.Fprintf(b, "- Error: %s\n", mdText(repo.Error))
+			continue
+		}
+		fmt.Fprintf(b, "- Success rate: %s over the last %d runs\n", successRate(repo.Stats), repo.Stats.Runs)
+		if repo.Stats.Recoveries > 0 {
+			fmt.Fprintf(b, "- Mean time to recovery: %s\n", formatDuration(repo.Stats.MTTR))
+		}
+		if repo.Stats.QueueMedian > 0 {
+			fmt.Fprintf(b, "- Queue time: %s median, %s p95\n", formatDuration(repo.Stats.QueueMedian), formatDuration(repo.Stats.QueueP95))
+		}
+
+		var failing []string
+		for _, run := range repo.Runs {
+			for _, job := range run.FailedJobs {
+				failing = append(failing, fmt.Sprintf("- %s #%d: %s\n", mdText(run.WorkflowName), run.RunNumber, mdText(job)))
+			}
+		}
+		if len(failing) > 0 {
+			fmt.Fprint(b, "\n### Failing jobs\n\n")
+			for _, line := range failing {
+				fmt.Fprint(b, line)
+			}
+		}
+
+		if len(repo.Runs) == 0 {
+			fmt.Fprint(b, "\nNo runs.\n")
+			continue
+		}
+		fmt.Fprint(b, "\n### Recent runs\n\n")
+		fmt.Fprintln(b, "| Run | Workflow | Branch | Result | Created | Duration |")
+		fmt.Fprintln(b, "|-----|----------|--------|--------|---------|----------|")
+		for _, run := range repo.Runs {
+			number := fmt.Sprintf("#%d", run.RunNumber)
+			if run.HTMLURL != "" {
+				number = fmt.Sprintf("[#%d](%s)", run.RunNumber, run.HTMLURL)
+			}
+			fmt.Fprintf(b, "| %s | %s | %s | %s | %s | %s |\n", number, mdCell(run.WorkflowName), mdCell(run.HeadBranch),
+				conclusion(run.WorkflowRun), formatTime(run.CreatedAt), formatDuration(run.Duration))
+		}
+	}
+	return b.Flush()
+}
+
+var mdEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", "&lt;", "\n", " ")
+
+func mdText(s string) string {
+	return mdEscaper.Replace(s)
+}
+
+func mdCell(s string) string {
+	return strings.ReplaceAll(mdText(s), "|", `\|`)
+}
+
+func writeCSV(w io.Writer, r Report) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"repo", "repo_status", "success_rate", "workflow", "run_number", "branch",
+		"status", "conclusion", "created_at", "duration_seconds", "failed_jobs", "url",
+	})
+	for _, repo := range r.Repos {
+		rate := ""
+		if repo.Stats.Succeeded+repo.Stats.Failed > 0 {
+			rate = strconv.FormatFloat(repo.Stats.SuccessRate, 'f', 3, 64)
+		}
+		if len(repo.Runs) == 0 {
+			_ = cw.Write([]string{repo.Name, repo.Status, rate, "", "", "", "", "", "", "", "", ""})
+			continue
+		}
+		for _, run := range repo.Runs {
+			created := ""
+			if !run.CreatedAt.IsZero() {
+				created = run.CreatedAt.UTC().Format("2006-01-02T15:04:05Z")
+			}
+			seconds := ""
+			if run.Duration > 0 {
+				seconds = strconv.Itoa(int(run.Duration.Seconds()))
+			}
+			_ = cw.Write([]string{
+				repo.Name, repo.Status, rate, run.WorkflowName, strconv.Itoa(run.RunNumber), run.HeadBranch,
+				run.Status, run.Conclusion, created, seconds, strings.Join(run.FailedJobs, ";"), run.HTMLURL,
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time":        formatTime,
+	"duration":    formatDuration,
+	"successRate": successRate,
+	"filters":     filters,
+	"conclusion":  conclusion,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  body { margin: 2rem; font: 14px/1.5 system-ui, sans-serif; color: #1f2328; }
+  table { border-collapse: collapse; margin: .5rem 0 1rem; }
+  th, td { border: 1px solid #d0d7de; padding: .25rem .6rem; text-align: left; }
+  th { background: #f6f8fa; }
+  .success { color: #1a7f37; } .failure, .error { color: #cf222e; }
+  .in_progress { color: #bf8700; } .pending, .cancelled, .unknown { color: #656d76; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if not .Repos}}
+<p>No repos.</p>
+{{- else}}
+<table>
+<tr><th>Repo</th><th>Status</th><th>Success rate</th><th>Latest run</th></tr>
+{{- range .Repos}}
+<tr><td><a href="#{{.Name}}">{{.Name}}</a></td><td class="{{.Status}}">{{.Status}}</td><td>{{successRate .Stats}}</td><td>{{with .Runs}}{{with index . 0}}{{.WorkflowName}} #{{.RunNumber}}{{end}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- range .Repos}}
+<h2 id="{{.Name}}">{{.Name}}</h2>
+<ul>
+<li>Status: <strong class="{{.Status}}">{{.Status}}</strong></li>
+{{- with filters .}}
+<li>Filter: {{.}}</li>
+{{- end}}
+{{- if .Error}}
+<li>Error: {{.Error}}</li>
+{{- else}}
+<li>Success rate: {{successRate .Stats}} over the last {{.Stats.Runs}} runs</li>
+{{- if .Stats.Recoveries}}
+<li>Mean time to recovery: {{duration .Stats.MTTR}}</li>
+{{- end}}
+{{- if .Stats.QueueMedian}}
+<li>Queue time: {{duration .Stats.QueueMedian}} median, {{duration .Stats.QueueP95}} p95</li>
+{{- end}}
+{{- end}}
+</ul>
+{{- if not .Error}}
+{{- $failing := false}}{{range .Runs}}{{if .FailedJobs}}{{$failing = true}}{{end}}{{end}}
+{{- if $failing}}
+<h3>Failing jobs</h3>
+<ul>
+{{- range .Runs}}{{$run := .}}{{range .FailedJobs}}
+<li>{{$run.WorkflowName}} #{{$run.RunNumber}}: {{.}}</li>
+{{- end}}{{end}}
+</ul>
+{{- end}}
+{{- if .Runs}}
+<h3>Recent runs</h3>
+<table>
+<tr><th>Run</th><th>Workflow</th><th>Branch</th><th>Result</th><th>Created</th><th>Duration</th></tr>
+{{- range .Runs}}
+<tr><td>{{if .HTMLURL}}<a href="{{.HTMLURL}}">#{{.RunNumber}}</a>{{else}}#{{.RunNumber}}{{end}}</td><td>{{.WorkflowName}}</td><td>{{.HeadBranch}}</td><td class="{{conclusion .WorkflowRun}}">{{conclusion .WorkflowRun}}</td><td>{{time .CreatedAt}}</td><td>{{duration .Duration}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No runs.</p>
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+func writeHTML(w io.Writer, r Report) error {
+	return htmlReport.Execute(w, r)
+}
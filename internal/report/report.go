@@ -0,0 +1,245 @@
+// Package report builds snapshots of a set of repos and writes them as
+// Markdown, HTML or CSV. Output only depends on the runs, never on when
+// the report was made, so reports can be committed and diffed.
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/poll"
+	"github.com/thesimpledev/ghflow/internal/stats"
+)
+
+// RecentRuns is how many runs each repo lists.
+const RecentRuns = 10
+
+// Formats are the output formats Write supports.
+var Formats = []string{"md", "html", "csv"}
+
+type Report struct {
+	Title string
+	Repos []Repo
+}
+
+// Repo is one repo's section of a report.
+type Repo struct {
+	Name     string // owner/name
+	Branch   string // Filters the dashboard applies, if any
+	Workflow string
+	Status   string
+	Error    string
+	Stats    stats.Report
+	Runs     []Run // Newest first
+}
+
+// Run is a recent run with the jobs that failed in it. Failed jobs are
+// only looked up for the latest completed run of each workflow.
+type Run struct {
+	github.WorkflowRun
+	Duration   time.Duration
+	FailedJobs []string
+}
+
+// Build makes a report from polled results, fetching the failed jobs of
+// each workflow whose latest run failed. Failed job lookups are best
+// effort.
+func Build(ctx context.Context, title string, results []poll.Result) Report {
+	r := Report{Title: title}
+	for _, res := range results {
+		repo := Repo{
+			Name:     res.Repo.FullName(),
+			Branch:   res.Repo.Branch,
+			Workflow: res.Repo.Workflow,
+			Stats:    stats.Compute(res.Repo.Branch, res.Runs),
+		}
+
+		runs := make([]github.WorkflowRun, len(res.Runs))
+		copy(runs, res.Runs)
+		sort.SliceStable(runs, func(i, j int) bool {
+			if !runs[i].CreatedAt.Equal(runs[j].CreatedAt) {
+				return runs[i].CreatedAt.After(runs[j].CreatedAt)
+			}
+			return runs[i].ID > runs[j].ID
+		})
+		repo.Status = string(poll.Result{Runs: runs}.Status())
+		if res.Err != nil {
+			repo.Status = "error"
+			repo.Error = res.Err.Error()
+		}
+		for i, run := range runs {
+			if i == RecentRuns {
+				break
+			}
+			repo.Runs = append(repo.Runs, Run{WorkflowRun: run, Duration: duration(run)})
+		}
+		r.Repos = append(r.Repos, repo)
+	}
+	sort.SliceStable(r.Repos, func(i, j int) bool {
+		return strings.ToLower(r.Repos[i].Name) < strings.ToLower(r.Repos[j].Name)
+	})
+
+	r.fetchFailedJobs(ctx)
+	return r
+}
+
+// fetchFailedJobs looks up failed jobs through poll.Requests, which caps
+// concurrent gh calls and shares them with a dashboard asking for the
+// same run.
+func (r Report) fetchFailedJobs(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := range r.Repos {
+		repo := &r.Repos[i]
+		owner, name, _ := strings.Cut(repo.Name, "/")
+		seen := map[string]bool{}
+		for j := range repo.Runs {
+			run := &repo.Runs[j]
+			if run.Status != "completed" || seen[run.WorkflowName] {
+				continue
+			}
+			seen[run.WorkflowName] = true
+			if !failed(run.Conclusion) {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				key := fmt.Sprintf("jobs:%s:%d", repo.Name, run.ID)
+				val, _, err := poll.Requests.Do(ctx, key, func(ctx context.Context) (any, error) {
+					return github.FetchRunJobs(ctx, owner, name, run.ID)
+				})
+				if err != nil {
+					return
+				}
+				jobs, _ := val.([]github.Job)
+				for _, job := range jobs {
+					if failed(job.Conclusion) {
+						run.FailedJobs = append(run.FailedJobs, job.Name)
+					}
+				}
+				sort.Strings(run.FailedJobs)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func failed(conclusion string) bool {
+	switch conclusion {
+	case "failure", "timed_out", "startup_failure":
+		return true
+	}
+	return false
+}
+
+func duration(run github.WorkflowRun) time.Duration {
+	if run.Status != "completed" {
+		return 0
+	}
+	return stats.RunDuration(run)
+}
+
+// Title is the heading of a report on profile, which may be empty.
+func Title(profile string) string {
+	if profile == "" {
+		return "CI report"
+	}
+	return "CI report: " + profile
+}
+
+// WriteFile writes r to path, replacing it only once the whole report is
+// written.
+func WriteFile(path, format string, r Report) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := Write(tmp, format, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil { // #nosec G302 -- reports are meant to be shared
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// FormatFromPath picks a format from a file's extension, Markdown unless
+// it's .html, .htm or .csv.
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return "html"
+	case ".csv":
+		return "csv"
+	}
+	return "md"
+}
+
+// Write writes r in format: md, html or csv.
+func Write(w io.Writer, format string, r Report) error {
+	switch format {
+	case "md":
+		return writeMarkdown(w, r)
+	case "html":
+		return writeHTML(w, r)
+	case "csv":
+		return writeCSV(w, r)
+	}
+	return fmt.Errorf("unknown format %q: use %s", format, strings.Join(Formats, ", "))
+}
+
+// Helpers shared by the formats. Times are UTC so reports made in
+// different time zones match.
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return d.Round(time.Second).String()
+}
+
+func successRate(s stats.Report) string {
+	total := s.Succeeded + s.Failed
+	if total == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.0f%% (%d/%d)", s.SuccessRate*100, s.Succeeded, total)
+}
+
+func conclusion(run github.WorkflowRun) string {
+	if run.Status != "completed" {
+		return run.Status
+	}
+	return run.Conclusion
+}
+
+func filters(repo Repo) string {
+	var parts []string
+	if repo.Branch != "" {
+		parts = append(parts, "branch "+repo.Branch)
+	}
+	if repo.Workflow != "" {
+		parts = append(parts, "workflow "+repo.Workflow)
+	}
+	return strings.Join(parts, ", ")
+}
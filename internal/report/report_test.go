@@ -0,0 +1,100 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/poll"
+)
+
+// fakeGH puts a gh on PATH that answers every jobs request with jobs.
+func fakeGH(t *testing.T, jobs string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\ncat <<'EOF'\n" + jobs + "\nEOF\n"
+	if err := os.WriteFile(filepath.Join(dir, "gh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestWriteIgnoresInputOrder(t *testing.T) {
+	fakeGH(t, `{"total_count": 3, "jobs": [
+  {"id": 3, "name": "test", "status": "completed", "conclusion": "failure"},
+  {"id": 2, "name": "build", "status": "completed", "conclusion": "success"},
+  {"id": 1, "name": "lint", "status": "completed", "conclusion": "timed_out"}
+]}`)
+
+	start := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	run := func(id int64, workflow, status, conclusion string, minutes int) github.WorkflowRun {
+		created := start.Add(time.Duration(minutes) * time.Minute)
+		return github.WorkflowRun{
+			ID:           id,
+			RunNumber:    int(id),
+			WorkflowName: workflow,
+			HeadBranch:   "main",
+			Status:       status,
+			Conclusion:   conclusion,
+			CreatedAt:    created,
+			RunStartedAt: created,
+			UpdatedAt:    created.Add(3 * time.Minute),
+		}
+	}
+	results := []poll.Result{
+		{Repo: config.Repo{Owner: "acme", Name: "api"}, Runs: []github.WorkflowRun{
+			run(1, "CI", "completed", "success", 0),
+			run(2, "CI", "completed", "failure", 10),
+			run(3, "Deploy", "completed", "success", 10), // Same time as 2
+			run(4, "CI", "in_progress", "", 20),
+		}},
+		{Repo: config.Repo{Owner: "Acme", Name: "Web", Branch: "dev"}, Runs: []github.WorkflowRun{
+			run(5, "CI", "completed", "failure", 0),
+			run(6, "CI", "completed", "cancelled", 5),
+		}},
+		{Repo: config.Repo{Owner: "acme", Name: "down"}, Err: os.ErrDeadlineExceeded},
+		{Repo: config.Repo{Owner: "acme", Name: "empty"}},
+	}
+
+	want := map[string][]byte{}
+	r := Build(context.Background(), "CI report", results)
+	for _, format := range Formats {
+		var buf bytes.Buffer
+		if err := Write(&buf, format, r); err != nil {
+			t.Fatalf("Write(%s): %v", format, err)
+		}
+		want[format] = buf.Bytes()
+	}
+	if !bytes.Contains(want["md"], []byte("- CI #2: lint\n- CI #2: test\n")) {
+		t.Errorf("failing jobs missing from markdown:\n%s", want["md"])
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 5; i++ {
+		shuffled := make([]poll.Result, len(results))
+		copy(shuffled, results)
+		rng.Shuffle(len(shuffled), func(a, b int) { shuffled[a], shuffled[b] = shuffled[b], shuffled[a] })
+		for j := range shuffled {
+			runs := append([]github.WorkflowRun(nil), shuffled[j].Runs...)
+			rng.Shuffle(len(runs), func(a, b int) { runs[a], runs[b] = runs[b], runs[a] })
+			shuffled[j].Runs = runs
+		}
+
+		r := Build(context.Background(), "CI report", shuffled)
+		for _, format := range Formats {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, r); err != nil {
+				t.Fatalf("Write(%s): %v", format, err)
+			}
+			if !bytes.Equal(buf.Bytes(), want[format]) {
+				t.Errorf("shuffle %d: %s output differs:\n%s\nwant:\n%s", i, format, buf.Bytes(), want[format])
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by calls on a client whose connection is gone.
+var ErrClosed = errors.New("connection closed")
+
+// Client calls methods on a Server and receives its notifications.
+type Client struct {
+	nc  net.Conn
+	enc *json.Encoder
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[string]chan Message
+	closed  bool
+
+	notes chan Message
+	done  chan struct{}
+}
+
+// Dial connects to the server listening on the Unix socket at path.
+func Dial(path string) (*Client, error) {
+	nc, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		nc:      nc,
+		enc:     json.NewEncoder(nc),
+		pending: map[string]chan Message{},
+		notes:   make(chan Message, 16),
+		done:    make(chan struct{}),
+	}
+	go c.read()
+	return c, nil
+}
+
+func (c *Client) read() {
+	defer func() {
+		c.mu.Lock()
+		c.closed = true
+		for id, ch := range c.pending {
+			close(ch)
+			delete(c.pending, id)
+		}
+		c.mu.Unlock()
+		close(c.notes)
+		close(c.done)
+	}()
+
+	scanner := bufio.NewScanner(c.nc)
+	scanner.Buffer(make([]byte, 64<<10), maxMessageSize)
+	for scanner.Scan() {
+		var m Message
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			continue
+		}
+		if m.ID == nil {
+			if m.Method != "" {
+				c.notes <- m
+			}
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[string(m.ID)]
+		delete(c.pending, string(m.ID))
+		c.mu.Unlock()
+		if ok {
+			ch <- m
+		}
+	}
+}
+
+// Notifications delivers the server's notifications. It's closed when
+// the connection goes away, and must be drained by clients that
+// subscribe.
+func (c *Client) Notifications() <-chan Message {
+	return c.notes
+}
+
+// Done is closed when the connection goes away.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Call sends a request and decodes its result into result, which may be
+// nil.
+func (c *Client) Call(ctx context.Context, method string, params, result any) error {
+	var raw json.RawMessage
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		raw = data
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.nextID++
+	id := json.RawMessage(strconv.FormatInt(c.nextID, 10))
+	ch := make(chan Message, 1)
+	c.pending[string(id)] = ch
+	err := c.enc.Encode(Message{JSONRPC: "2.0", ID: id, Method: method, Params: raw})
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, string(id))
+		c.mu.Unlock()
+		return ctx.Err()
+	case resp, ok := <-ch:
+		if !ok {
+			return ErrClosed
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || resp.Result == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	}
+}
+
+func (c *Client) Close() error {
+	return c.nc.Close()
+}
@@ -0,0 +1,259 @@
+// Package rpc is a small JSON-RPC 2.0 server and client over Unix
+// sockets, one JSON object per line. Servers can also push notifications
+// to connected clients.
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Standard JSON-RPC error codes, plus the one handlers' errors get.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeServerError    = -32000
+)
+
+// maxMessageSize bounds one line of JSON either way.
+const maxMessageSize = 16 << 20
+
+// Message is a request, a response or a notification; which one depends
+// on which fields are set.
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errorf returns an error that's sent to the client with code rather
+// than CodeServerError.
+func Errorf(code int, format string, args ...any) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// DecodeParams unmarshals a request's params into v, leaving v alone
+// when there are none.
+func DecodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return Errorf(CodeInvalidParams, "invalid params: %v", err)
+	}
+	return nil
+}
+
+// Handler answers one method. Its result is marshalled as the response.
+type Handler func(ctx context.Context, conn *Conn, params json.RawMessage) (any, error)
+
+// Server dispatches requests from every connection to its handlers.
+type Server struct {
+	mu           sync.Mutex
+	handlers     map[string]Handler
+	conns        map[*Conn]struct{}
+	onDisconnect func(*Conn)
+}
+
+func NewServer() *Server {
+	return &Server{
+		handlers: map[string]Handler{},
+		conns:    map[*Conn]struct{}{},
+	}
+}
+
+// Handle registers h for method. Register everything before Serve.
+func (s *Server) Handle(method string, h Handler) {
+	s.handlers[method] = h
+}
+
+// OnDisconnect sets a function called when a client goes away.
+func (s *Server) OnDisconnect(f func(*Conn)) {
+	s.onDisconnect = f
+}
+
+// Serve accepts connections on ln until ctx is done.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	for {
+		nc, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		conn := &Conn{nc: nc, enc: json.NewEncoder(nc)}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go s.serveConn(ctx, conn)
+	}
+}
+
+func (s *Server) serveConn(ctx context.Context, conn *Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		conn.nc.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		if s.onDisconnect != nil {
+			s.onDisconnect(conn)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		conn.nc.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn.nc)
+	scanner.Buffer(make([]byte, 64<<10), maxMessageSize)
+	for scanner.Scan() {
+		var req Message
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			_ = conn.send(Message{Error: &Error{Code: CodeParseError, Message: "parse error"}})
+			continue
+		}
+		if req.Method == "" {
+			_ = conn.send(Message{ID: req.ID, Error: &Error{Code: CodeInvalidRequest, Message: "missing method"}})
+			continue
+		}
+		// Requests on a connection are answered in order; handlers are
+		// expected to be quick
+		resp := s.call(ctx, conn, req)
+		if req.ID != nil {
+			_ = conn.send(resp)
+		}
+	}
+}
+
+func (s *Server) call(ctx context.Context, conn *Conn, req Message) Message {
+	resp := Message{ID: req.ID}
+	h, ok := s.handlers[req.Method]
+	if !ok {
+		resp.Error = &Error{Code: CodeMethodNotFound, Message: "unknown method " + req.Method}
+		return resp
+	}
+	result, err := h(ctx, conn, req.Params)
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeServerError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &Error{Code: CodeServerError, Message: err.Error()}
+		return resp
+	}
+	resp.Result = data
+	return resp
+}
+
+// Broadcast sends a notification to every client that subscribed.
+func (s *Server) Broadcast(method string, params any) {
+	s.mu.Lock()
+	conns := make([]*Conn, 0, len(s.conns))
+	for conn := range s.conns {
+		if conn.Subscribed() {
+			conns = append(conns, conn)
+		}
+	}
+	s.mu.Unlock()
+	for _, conn := range conns {
+		_ = conn.Notify(method, params)
+	}
+}
+
+// Conn is a client connected to a Server.
+type Conn struct {
+	nc  net.Conn
+	mu  sync.Mutex
+	enc *json.Encoder
+
+	subscribed bool
+}
+
+// Subscribe marks the client as wanting Broadcast notifications.
+func (c *Conn) Subscribe() {
+	c.mu.Lock()
+	c.subscribed = true
+	c.mu.Unlock()
+}
+
+func (c *Conn) Subscribed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.subscribed
+}
+
+// Notify sends a notification to this client alone.
+func (c *Conn) Notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.send(Message{Method: method, Params: data})
+}
+
+func (c *Conn) send(m Message) error {
+	m.JSONRPC = "2.0"
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// A client that stops reading shouldn't block the server forever
+	_ = c.nc.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	return c.enc.Encode(m)
+}
+
+// Listen listens on the Unix socket at path, readable only by the user.
+// A socket left behind by a process that died is replaced; one that's
+// still answering is an error.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if nc, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		nc.Close()
+		return nil, fmt.Errorf("%s is already in use", path)
+	}
+	_ = os.Remove(path)
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
@@ -0,0 +1,158 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+// Report summarises the health of a set of runs, typically the recent
+// history of a repo's default branch.
+type Report struct {
+	Branch string
+	Runs   int // Runs considered, including ones still in progress
+
+	// Success rate over completed runs that passed or failed. Cancelled
+	// and skipped runs don't count either way.
+	Succeeded   int
+	Failed      int
+	SuccessRate float64
+
+	// MTTR is the mean time from a workflow turning red to it turning
+	// green again, over Recoveries such periods.
+	MTTR       time.Duration
+	Recoveries int
+
+	QueueMedian time.Duration
+	QueueP95    time.Duration
+
+	Workflows []WorkflowStats
+}
+
+// WorkflowStats holds per-workflow run durations.
+type WorkflowStats struct {
+	Name           string
+	Runs           int
+	DurationMedian time.Duration
+	DurationP95    time.Duration
+}
+
+// Compute builds a report from runs. Order doesn't matter.
+func Compute(branch string, runs []github.WorkflowRun) Report {
+	report := Report{Branch: branch, Runs: len(runs)}
+
+	// Oldest first so red/green periods are walked in order. Run IDs
+	// break ties between runs created in the same second.
+	sorted := make([]github.WorkflowRun, len(runs))
+	copy(sorted, runs)
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var queues []time.Duration
+	durations := map[string][]time.Duration{}
+	var workflowOrder []string
+	redSince := map[string]time.Time{}
+	var recoveryTotal time.Duration
+
+	for _, run := range sorted {
+		if q := QueueTime(run); q > 0 {
+			queues = append(queues, q)
+		}
+
+		if run.Status != "completed" {
+			continue
+		}
+
+		name := run.WorkflowName
+		if _, ok := durations[name]; !ok {
+			durations[name] = nil
+			workflowOrder = append(workflowOrder, name)
+		}
+		if d := RunDuration(run); d > 0 {
+			durations[name] = append(durations[name], d)
+		}
+
+		switch run.Conclusion {
+		case "success":
+			report.Succeeded++
+			if start, red := redSince[name]; red {
+				recoveryTotal += run.UpdatedAt.Sub(start)
+				report.Recoveries++
+				delete(redSince, name)
+			}
+		case "failure", "timed_out", "startup_failure":
+			report.Failed++
+			if _, red := redSince[name]; !red {
+				redSince[name] = run.UpdatedAt
+			}
+		}
+	}
+
+	if total := report.Succeeded + report.Failed; total > 0 {
+		report.SuccessRate = float64(report.Succeeded) / float64(total)
+	}
+	if report.Recoveries > 0 {
+		report.MTTR = recoveryTotal / time.Duration(report.Recoveries)
+	}
+	report.QueueMedian = Percentile(queues, 50)
+	report.QueueP95 = Percentile(queues, 95)
+
+	for _, name := range workflowOrder {
+		ds := durations[name]
+		report.Workflows = append(report.Workflows, WorkflowStats{
+			Name:           name,
+			Runs:           len(ds),
+			DurationMedian: Percentile(ds, 50),
+			DurationP95:    Percentile(ds, 95),
+		})
+	}
+	sort.SliceStable(report.Workflows, func(i, j int) bool {
+		return report.Workflows[i].Name < report.Workflows[j].Name
+	})
+
+	return report
+}
+
+// RunDuration is how long a completed run took once it started, or zero
+// when that isn't known.
+func RunDuration(run github.WorkflowRun) time.Duration {
+	start := run.RunStartedAt
+	if start.IsZero() {
+		start = run.CreatedAt
+	}
+	if start.IsZero() || run.UpdatedAt.Before(start) {
+		return 0
+	}
+	return run.UpdatedAt.Sub(start)
+}
+
+// QueueTime is how long a run waited before it started, or zero when it
+// hasn't started.
+func QueueTime(run github.WorkflowRun) time.Duration {
+	if run.RunStartedAt.IsZero() || run.RunStartedAt.Before(run.CreatedAt) {
+		return 0
+	}
+	return run.RunStartedAt.Sub(run.CreatedAt)
+}
+
+// Percentile returns the p-th percentile (nearest rank) of ds, or zero
+// for an empty slice.
+func Percentile(ds []time.Duration, p int) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(ds))
+	copy(sorted, ds)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,148 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+var start = time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+
+func minutes(n int) time.Duration { return time.Duration(n) * time.Minute }
+
+// run makes a run of workflow created at minute created that queued for
+// queue minutes and then ran for took minutes. A run without a conclusion
+// is still in progress.
+func run(id int64, workflow, conclusion string, created, queue, took int) github.WorkflowRun {
+	r := github.WorkflowRun{
+		ID:           id,
+		WorkflowName: workflow,
+		Status:       "completed",
+		Conclusion:   conclusion,
+		CreatedAt:    start.Add(minutes(created)),
+		RunStartedAt: start.Add(minutes(created + queue)),
+		UpdatedAt:    start.Add(minutes(created + queue + took)),
+	}
+	if conclusion == "" {
+		r.Status = "in_progress"
+	}
+	return r
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name string
+		runs []github.WorkflowRun
+		want Report
+	}{
+		{
+			name: "no runs",
+			want: Report{},
+		},
+		{
+			name: "red and green periods per workflow",
+			runs: []github.WorkflowRun{
+				run(1, "CI", "failure", 0, 0, 5),        // CI red from 5
+				run(2, "Deploy", "timed_out", 10, 0, 5), // Deploy red from 15
+				run(3, "CI", "failure", 20, 0, 5),       // Still red
+				run(4, "CI", "success", 30, 0, 5),       // Green at 35: 30m
+				run(5, "Deploy", "success", 60, 0, 5),   // Green at 65: 50m
+			},
+			want: Report{Runs: 5, Succeeded: 2, Failed: 3, SuccessRate: 0.4, MTTR: minutes(40), Recoveries: 2},
+		},
+		{
+			name: "cancelled runs excluded",
+			runs: []github.WorkflowRun{
+				run(1, "CI", "failure", 0, 0, 5),
+				run(2, "CI", "cancelled", 10, 0, 5), // Neither counts nor ends the red period
+				run(3, "CI", "skipped", 15, 0, 0),
+				run(4, "CI", "success", 20, 0, 5),
+			},
+			want: Report{Runs: 4, Succeeded: 1, Failed: 1, SuccessRate: 0.5, MTTR: minutes(20), Recoveries: 1},
+		},
+		{
+			name: "still red",
+			runs: []github.WorkflowRun{
+				run(1, "CI", "success", 0, 0, 5),
+				run(2, "CI", "failure", 10, 0, 5),
+			},
+			want: Report{Runs: 2, Succeeded: 1, Failed: 1, SuccessRate: 0.5},
+		},
+		{
+			name: "runs in progress",
+			runs: []github.WorkflowRun{
+				run(1, "CI", "success", 0, 1, 5),
+				run(2, "CI", "", 10, 3, 0), // Started, counts towards queue time only
+				func() github.WorkflowRun {
+					r := run(3, "CI", "", 20, 0, 0)
+					r.Status, r.RunStartedAt = "queued", time.Time{}
+					return r
+				}(),
+			},
+			want: Report{Runs: 3, Succeeded: 1, SuccessRate: 1, QueueMedian: minutes(1), QueueP95: minutes(3)},
+		},
+	}
+	for _, tt := range tests {
+		got := Compute("main", tt.runs)
+		got.Workflows = nil
+		tt.want.Branch = "main"
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Compute() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestComputeIgnoresOrder(t *testing.T) {
+	runs := []github.WorkflowRun{
+		run(1, "CI", "failure", 0, 2, 5),
+		run(2, "CI", "success", 0, 1, 7), // Created with 1
+		run(3, "Lint", "failure", 5, 0, 1),
+		run(4, "Lint", "success", 10, 0, 1),
+		run(5, "CI", "", 20, 4, 0),
+	}
+	want := Compute("", runs)
+	if len(want.Workflows) != 2 || want.Workflows[0].Name != "CI" || want.Workflows[0].Runs != 2 {
+		t.Fatalf("Workflows = %+v, want CI with 2 runs then Lint", want.Workflows)
+	}
+
+	reversed := make([]github.WorkflowRun, len(runs))
+	for i, r := range runs {
+		reversed[len(runs)-1-i] = r
+	}
+	if got := Compute("", reversed); !reflect.DeepEqual(got, want) {
+		t.Errorf("Compute(reversed) = %+v, want %+v", got, want)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	seq := func(n int) []time.Duration {
+		ds := make([]time.Duration, n)
+		for i := range ds {
+			ds[i] = time.Duration(n-i) * time.Second // Descending
+		}
+		return ds
+	}
+
+	tests := []struct {
+		name string
+		ds   []time.Duration
+		p    int
+		want time.Duration
+	}{
+		{"empty", nil, 50, 0},
+		{"one", seq(1), 95, time.Second},
+		{"median of even count", seq(4), 50, 2 * time.Second},
+		{"median rounds up", seq(5), 50, 3 * time.Second},
+		{"p95 of ten", seq(10), 95, 10 * time.Second},
+		{"p95 of twenty", seq(20), 95, 19 * time.Second},
+		{"p0 is the smallest", seq(3), 0, time.Second},
+		{"p100 is the largest", seq(3), 100, 3 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := Percentile(tt.ds, tt.p); got != tt.want {
+			t.Errorf("%s: Percentile(%d) = %v, want %v", tt.name, tt.p, got, tt.want)
+		}
+	}
+}
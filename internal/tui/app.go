@@ -1,12 +1,17 @@
 package tui
 
 import (
+	"context"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/daemon"
+	"github.com/thesimpledev/ghflow/internal/poll"
+	"github.com/thesimpledev/ghflow/internal/tui/components"
 	"github.com/thesimpledev/ghflow/internal/tui/views"
+	"github.com/thesimpledev/ghflow/internal/webhook"
 )
 
 type App struct {
@@ -14,26 +19,81 @@ type App struct {
 	dashboard views.DashboardModel
 	width     int
 	height    int
+	paused    bool // Terminal lost focus; polling waits until it's back
+	events    <-chan webhook.Event
+	daemon    *daemon.Client
 }
 
 type TickMsg time.Time
 
-func NewApp(cfg *config.Config) App {
+// NewApp creates the TUI. Cancelling ctx aborts every in-flight request.
+func NewApp(ctx context.Context, cfg *config.Config) App {
 	return App{
 		config:    cfg,
-		dashboard: views.NewDashboardModel(cfg),
+		dashboard: views.NewDashboardModel(ctx, cfg),
 	}
 }
 
+// SetRunEvents feeds pushed webhook events into the dashboard.
+func (a App) SetRunEvents(events <-chan webhook.Event) App {
+	a.events = events
+	return a
+}
+
+// SetDaemon has a running daemon poll for the dashboard instead of
+// polling directly.
+func (a App) SetDaemon(c *daemon.Client) App {
+	a.daemon = c
+	a.dashboard = a.dashboard.SetRemote(c)
+	return a
+}
+
 func (a App) Init() tea.Cmd {
 	return tea.Batch(
 		a.dashboard.Init(),
 		tickCmd(),
+		waitForEvent(a.events),
+		waitForUpdate(a.daemon),
 	)
 }
 
+// waitForUpdate delivers the daemon's next update as a RemoteStatusMsg,
+// or DaemonLostMsg once it's gone.
+func waitForUpdate(c *daemon.Client) tea.Cmd {
+	if c == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		states, ok := <-c.Updates()
+		if !ok {
+			return views.DaemonLostMsg{}
+		}
+		results := make([]poll.Result, len(states))
+		for i, s := range states {
+			results[i] = s.Result()
+		}
+		return components.RemoteStatusMsg{Results: results}
+	}
+}
+
+// waitForEvent delivers the next webhook event as a RunEventMsg.
+func waitForEvent(events <-chan webhook.Event) tea.Cmd {
+	if events == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		event, ok := <-events
+		if !ok {
+			return nil
+		}
+		return components.RunEventMsg{Repo: event.Repo, Run: event.Run, Job: event.Job}
+	}
+}
+
+// tickCmd drives polling. Each card keeps its own interval, so the tick
+// only needs to be fine-grained enough to notice when one is due.
 func tickCmd() tea.Cmd {
-	return tea.Tick(30*time.Second, func(t time.Time) tea.Msg {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
 		return TickMsg(t)
 	})
 }
@@ -49,10 +109,40 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case TickMsg:
 		cmds = append(cmds, tickCmd())
+		if a.paused {
+			return a, tea.Batch(cmds...)
+		}
 		var cmd tea.Cmd
-		a.dashboard, cmd = a.dashboard.Update(views.RefreshMsg{})
+		a.dashboard, cmd = a.dashboard.Update(views.PollMsg(msg))
 		cmds = append(cmds, cmd)
 		return a, tea.Batch(cmds...)
+
+	case components.RunEventMsg:
+		cmds = append(cmds, waitForEvent(a.events))
+
+	case components.RemoteStatusMsg:
+		cmds = append(cmds, waitForUpdate(a.daemon))
+
+	case views.DaemonLostMsg:
+		a.daemon = nil
+
+	case controlMsg:
+		var cmd tea.Cmd
+		var reply controlReply
+		a, cmd, reply = a.control(msg)
+		msg.reply <- reply
+		return a, cmd
+
+	case tea.BlurMsg:
+		a.paused = true
+		return a, nil
+
+	case tea.FocusMsg:
+		// Catch up on everything missed while away
+		a.paused = false
+		var cmd tea.Cmd
+		a.dashboard, cmd = a.dashboard.Update(views.RefreshMsg{})
+		return a, cmd
 	}
 
 	var cmd tea.Cmd
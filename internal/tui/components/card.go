@@ -1,6 +1,7 @@
 package components
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -8,8 +9,13 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/thesimpledev/ghflow/internal/cache"
 	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/flaky"
 	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/history"
+	"github.com/thesimpledev/ghflow/internal/poll"
+	"github.com/thesimpledev/ghflow/internal/stats"
 )
 
 type CardState int
@@ -19,52 +25,256 @@ const (
 	CardSelected
 	CardFocused
 	CardRunDetail
+	CardStats
+	CardFlaky
 )
 
 type Card struct {
-	Repo        config.Repo
-	Runs        []github.WorkflowRun
-	Status      github.RunStatus
-	Error       error
-	State       CardState
-	Width       int
-	Height      int
-	ScrollPos   int
-	RunCursor   int
-	DetailRun   *github.WorkflowRun
-	DetailJobs  []github.Job
-	JobCursor   int
-	LoadingJobs bool
+	Repo         config.Repo
+	Runs         []github.WorkflowRun
+	Status       github.RunStatus
+	Error        error
+	State        CardState
+	Width        int
+	Height       int
+	ScrollPos    int
+	RunCursor    int
+	DetailRun    *github.WorkflowRun
+	DetailJobs   []github.Job
+	DetailFlaky  map[string]bool // flaky.Key of DetailJobs seen flaking before
+	JobCursor    int
+	LoadingJobs  bool
+	RerunPending bool      // A re-run of DetailRun was asked for and hasn't returned
+	RerunNote    string    // Outcome of the last re-run asked for from the detail view
+	Gen          uint64    // Request generation of the runs currently shown
+	FetchedAt    time.Time // Last successful refresh; zero if unknown
+	Stale        bool      // Runs shown are from cache or an earlier refresh
+	Stats        *stats.Report
+	StatsErr     error
+	LoadingStats bool
+	FlakyJobs    []flaky.Job
+	FlakyErr     error
+	LoadingFlaky bool
+
+	// ctx is cancelled when the card leaves the grid
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	pollInterval time.Duration
+	nextPoll     time.Time
+
+	// live is set once runs were fetched this session rather than loaded
+	// from disk
+	live bool
 }
 
-func NewCard(repo config.Repo) Card {
+func NewCard(ctx context.Context, repo config.Repo) Card {
+	ctx, cancel := context.WithCancel(ctx)
 	return Card{
 		Repo:   repo,
 		Status: github.StatusUnknown,
 		Runs:   []github.WorkflowRun{},
+		ctx:    ctx,
+		cancel: cancel,
 	}
 }
 
+// Close cancels the card's in-flight requests.
+func (c Card) Close() {
+	c.cancel()
+}
+
+// loadCache shows the last successful response for this repo until the
+// first refresh lands. Cards that already have runs are left alone.
+func (c Card) loadCache(store *cache.Cache) Card {
+	if store == nil || len(c.Runs) > 0 {
+		return c
+	}
+	snap, err := store.Load(c.Repo)
+	if err != nil || snap == nil || len(snap.Runs) == 0 {
+		return c
+	}
+	runs := poll.FilterRuns(c.Repo, snap.Runs)
+	if len(runs) == 0 {
+		return c
+	}
+	c.Runs = runs
+	c.Status = runs[0].RunStatus()
+	c.FetchedAt = snap.FetchedAt
+	c.Stale = true
+	return c
+}
+
+// loadHistory is the fallback for loadCache when no snapshot exists.
+func loadHistory(store *history.Store, repo config.Repo) tea.Cmd {
+	return func() tea.Msg {
+		runs, err := store.LatestRuns(repo.FullName(), poll.FilteredFetchSize)
+		if err != nil {
+			return nil
+		}
+		return HistoryLoadedMsg{Repo: repo, Runs: runs}
+	}
+}
+
+// applyHistory shows runs from history, unless the card found something
+// better to show in the meantime.
+func (c Card) applyHistory(runs []github.WorkflowRun) Card {
+	if len(c.Runs) > 0 {
+		return c
+	}
+	runs = poll.FilterRuns(c.Repo, runs)
+	if len(runs) == 0 {
+		return c
+	}
+	c.Runs = runs
+	c.Status = runs[0].RunStatus()
+	c.Stale = true
+	return c
+}
+
+// reschedule picks the card's next poll time after a refresh. Active runs
+// poll at the minimum interval; otherwise the interval resets when
+// something changed and doubles when nothing did.
+func (c Card) reschedule(prevRuns []github.WorkflowRun, now time.Time, minInterval, maxInterval time.Duration) Card {
+	switch {
+	case c.Error != nil:
+		// Retry steadily so we notice quickly when GitHub is back
+		c.pollInterval = idlePollStart
+	case c.hasActiveRun():
+		c.pollInterval = minInterval
+	case c.Error == nil && runsChanged(prevRuns, c.Runs):
+		c.pollInterval = idlePollStart
+	case c.pollInterval == 0:
+		c.pollInterval = idlePollStart
+	default:
+		c.pollInterval *= 2
+	}
+	c.pollInterval = clampDuration(c.pollInterval, minInterval, maxInterval)
+	c.nextPoll = now.Add(c.pollInterval)
+	return c
+}
+
+// applyRun merges a pushed run into the card, replacing the run with the
+// same ID or inserting it in newest-first order. Deliveries can arrive out
+// of order, so a run older than the one the card has is ignored. It
+// reports whether the card changed.
+func (c Card) applyRun(run github.WorkflowRun) (Card, bool) {
+	if !c.Repo.MatchesRun(run.HeadBranch, run.WorkflowName) {
+		return c, false
+	}
+	runs := make([]github.WorkflowRun, 0, len(c.Runs)+1)
+	inserted := false
+	for _, r := range c.Runs {
+		if r.ID == run.ID {
+			if r.UpdatedAt.After(run.UpdatedAt) {
+				return c, false
+			}
+			continue
+		}
+		if !inserted && run.CreatedAt.After(r.CreatedAt) {
+			runs = append(runs, run)
+			inserted = true
+		}
+		runs = append(runs, r)
+	}
+	if !inserted {
+		runs = append(runs, run)
+	}
+	if len(runs) > poll.RunsPerRepo {
+		runs = runs[:poll.RunsPerRepo]
+	}
+
+	c.Runs = runs
+	c.Status = runs[0].RunStatus()
+	c.Error = nil
+	if c.DetailRun != nil && c.DetailRun.ID == run.ID {
+		c.DetailRun = &run
+	}
+	return c, true
+}
+
+// applyJob updates a job in the run detail view, if that run is open.
+func (c Card) applyJob(job github.Job) Card {
+	if c.DetailRun == nil || c.DetailRun.ID != job.RunID {
+		return c
+	}
+	jobs := make([]github.Job, len(c.DetailJobs))
+	copy(jobs, c.DetailJobs)
+	for i := range jobs {
+		if jobs[i].ID == job.ID {
+			jobs[i] = job
+			c.DetailJobs = jobs
+			return c
+		}
+	}
+	c.DetailJobs = append(jobs, job)
+	return c
+}
+
+func (c Card) hasActiveRun() bool {
+	for _, run := range c.Runs {
+		switch run.RunStatus() {
+		case github.StatusInProgress, github.StatusPending:
+			return true
+		}
+	}
+	return false
+}
+
+func runsChanged(prev, next []github.WorkflowRun) bool {
+	if len(prev) != len(next) {
+		return true
+	}
+	for i := range prev {
+		if prev[i].ID != next[i].ID || prev[i].Status != next[i].Status || prev[i].Conclusion != next[i].Conclusion {
+			return true
+		}
+	}
+	return false
+}
+
+func clampDuration(d, lo, hi time.Duration) time.Duration {
+	if d < lo {
+		return lo
+	}
+	if d > hi {
+		return hi
+	}
+	return d
+}
+
 func (c Card) SetSize(width, height int) Card {
 	c.Width = width
 	c.Height = height
 	return c
 }
 
+// JobsFetchedMsg delivers the jobs of RunID to the card for Repo.
 type JobsFetchedMsg struct {
-	CardIndex int
-	Jobs      []github.Job
-	Error     error
+	Repo  string
+	RunID int64
+	Jobs  []github.Job
+	Flaky map[string]bool
+	Error error
+	Key   string
+	Gen   uint64
 }
 
 func (c Card) SetState(state CardState) Card {
 	c.State = state
-	if state != CardFocused && state != CardRunDetail {
+	if state != CardFocused && state != CardRunDetail && state != CardStats && state != CardFlaky {
 		c.RunCursor = 0
 		c.ScrollPos = 0
 		c.DetailRun = nil
 		c.DetailJobs = nil
+		c.DetailFlaky = nil
 		c.JobCursor = 0
+		c.RerunPending = false
+		c.RerunNote = ""
+		c.Stats = nil
+		c.StatsErr = nil
+		c.FlakyJobs = nil
+		c.FlakyErr = nil
 	}
 	return c
 }
@@ -72,11 +282,64 @@ func (c Card) SetState(state CardState) Card {
 func (c Card) Update(msg tea.Msg) (Card, tea.Cmd) {
 	switch msg := msg.(type) {
 	case JobsFetchedMsg:
+		if c.DetailRun == nil || c.DetailRun.ID != msg.RunID {
+			// The user already left this run's detail view
+			return c, nil
+		}
 		c.LoadingJobs = false
 		c.DetailJobs = msg.Jobs
+		c.DetailFlaky = msg.Flaky
+		return c, nil
+
+	case RerunMsg:
+		if c.DetailRun == nil || c.DetailRun.ID != msg.RunID {
+			return c, nil
+		}
+		c.RerunPending = false
+		if msg.Err != nil {
+			c.RerunNote = "could not re-run: " + msg.Err.Error()
+		} else {
+			c.RerunNote = "re-run requested"
+		}
+		return c, nil
+
+	case StatsFetchedMsg:
+		if c.State != CardStats {
+			return c, nil
+		}
+		c.LoadingStats = false
+		c.Stats = msg.Report
+		c.StatsErr = msg.Error
+		return c, nil
+
+	case FlakyFetchedMsg:
+		if c.State != CardFlaky {
+			return c, nil
+		}
+		c.LoadingFlaky = false
+		c.FlakyJobs = msg.Jobs
+		c.FlakyErr = msg.Error
 		return c, nil
 
 	case tea.KeyMsg:
+		if c.State == CardStats {
+			if msg.String() == "esc" {
+				c.State = CardFocused
+				c.Stats = nil
+				c.StatsErr = nil
+			}
+			return c, nil
+		}
+
+		if c.State == CardFlaky {
+			if msg.String() == "esc" {
+				c.State = CardFocused
+				c.FlakyJobs = nil
+				c.FlakyErr = nil
+			}
+			return c, nil
+		}
+
 		if c.State == CardRunDetail {
 			// In run detail view - navigate jobs
 			switch msg.String() {
@@ -88,12 +351,22 @@ func (c Card) Update(msg tea.Msg) (Card, tea.Cmd) {
 				if c.JobCursor > 0 {
 					c.JobCursor--
 				}
+			case "R", "F":
+				// The grid sends the request; it knows whether a daemon
+				// is attached
+				if c.DetailRun.Status == "completed" && !c.RerunPending {
+					c.RerunPending = true
+					c.RerunNote = "re-running..."
+				}
 			case "esc":
 				// Back to run list
 				c.State = CardFocused
 				c.DetailRun = nil
 				c.DetailJobs = nil
+				c.DetailFlaky = nil
 				c.JobCursor = 0
+				c.RerunPending = false
+				c.RerunNote = ""
 			}
 			return c, nil
 		}
@@ -119,12 +392,24 @@ func (c Card) Update(msg tea.Msg) (Card, tea.Cmd) {
 			case "enter":
 				if c.RunCursor < len(c.Runs) {
 					run := c.Runs[c.RunCursor]
+					// The grid starts the fetch; it owns the history store
 					c.State = CardRunDetail
 					c.DetailRun = &run
+					c.DetailFlaky = nil
 					c.LoadingJobs = true
 					c.JobCursor = 0
-					return c, c.fetchJobs(run.ID)
 				}
+			case "s":
+				// The grid starts the fetch; it knows the stats window
+				c.State = CardStats
+				c.LoadingStats = true
+				c.Stats = nil
+				c.StatsErr = nil
+			case "f":
+				c.State = CardFlaky
+				c.LoadingFlaky = true
+				c.FlakyJobs = nil
+				c.FlakyErr = nil
 			}
 			return c, nil
 		}
@@ -133,34 +418,33 @@ func (c Card) Update(msg tea.Msg) (Card, tea.Cmd) {
 	return c, nil
 }
 
-func (c Card) fetchJobs(runID int64) tea.Cmd {
-	owner := c.Repo.Owner
-	name := c.Repo.Name
-	return func() tea.Msg {
-		jobs, err := github.FetchRunJobs(owner, name, runID)
-		return JobsFetchedMsg{
-			Jobs:  jobs,
-			Error: err,
-		}
-	}
-}
-
 func (c Card) visibleRunCount() int {
 	// Height minus header (repo name + status + divider) = runs area
-	// Header takes about 3 lines
+	// Header takes about 3 lines, plus one for the duration sparkline
 	available := c.Height - 5
+	if c.showDurationSparkline() {
+		available--
+	}
 	if available < 1 {
 		return 1
 	}
 	return available
 }
 
+// minRunsWithDurations is how many run lines must still fit before the
+// duration sparkline gets its own line.
+const minRunsWithDurations = 5
+
+func (c Card) showDurationSparkline() bool {
+	return c.Height-6 >= minRunsWithDurations && len(c.Runs) > 1
+}
+
 func (c Card) View() string {
 	var borderColor lipgloss.Color
 	var borderStyle lipgloss.Border
 
 	switch c.State {
-	case CardFocused, CardRunDetail:
+	case CardFocused, CardRunDetail, CardStats, CardFlaky:
 		borderColor = lipgloss.Color("62") // Purple
 		borderStyle = lipgloss.ThickBorder()
 	case CardSelected:
@@ -174,14 +458,19 @@ func (c Card) View() string {
 	cardStyle := lipgloss.NewStyle().
 		Border(borderStyle).
 		BorderForeground(borderColor).
-		Width(c.Width - 2).
-		Height(c.Height - 2).
+		Width(c.Width-2).
+		Height(c.Height-2).
 		Padding(0, 1)
 
 	var content string
-	if c.State == CardRunDetail {
+	switch c.State {
+	case CardRunDetail:
 		content = c.renderRunDetail()
-	} else {
+	case CardStats:
+		content = c.renderStats()
+	case CardFlaky:
+		content = c.renderFlaky()
+	default:
 		content = c.renderContent()
 	}
 	return cardStyle.Render(content)
@@ -210,7 +499,11 @@ func (c Card) renderContent() string {
 		repoName = repoName[:truncLen] + "..."
 	}
 	nameStyle := lipgloss.NewStyle().Bold(true)
-	b.WriteString(nameStyle.Render(repoName) + "\n")
+	nameLine := nameStyle.Render(repoName)
+	if label := c.filterLabel(); label != "" && len(repoName)+len(label)+1 <= maxNameLen {
+		nameLine += " " + lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(label)
+	}
+	b.WriteString(nameLine + "\n")
 
 	// Status line - use a dot indicator instead of [ok] to differentiate from run entries
 	statusDot := c.statusDot()
@@ -221,10 +514,39 @@ func (c Card) renderContent() string {
 			branch = branch[:12] + "..."
 		}
 	}
+	staleLabel := ""
+	if c.Stale {
+		staleLabel = c.staleLabel()
+	}
+
+	// Outcome sparkline fills whatever room the dot, branch and stale
+	// marker leave
+	sparkWidth := width - 4 - 2 - len(branch) - 1
+	if staleLabel != "" {
+		sparkWidth -= len(staleLabel) + 1
+	}
+	sparkWidth = min(sparkWidth, poll.RunsPerRepo)
+
 	branchStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
-	statusLine := fmt.Sprintf("%s %s", statusDot, branchStyle.Render(branch))
+	statusLine := statusDot
+	if sparkWidth >= 5 && len(c.Runs) > 1 {
+		statusLine += " " + outcomeSparkline(c.Runs, sparkWidth)
+	}
+	statusLine += " " + branchStyle.Render(branch)
+	if staleLabel != "" {
+		staleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		statusLine += " " + staleStyle.Render(staleLabel)
+	}
 	b.WriteString(statusLine + "\n")
 
+	if c.showDurationSparkline() {
+		if spark := durationSparkline(c.Runs, min(width-4-4, poll.RunsPerRepo)); spark != "" {
+			dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+			b.WriteString(dimStyle.Render("dur ") + spark)
+		}
+		b.WriteString("\n")
+	}
+
 	// Divider
 	dividerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 	dividerWidth := width - 4
@@ -235,7 +557,7 @@ func (c Card) renderContent() string {
 	b.WriteString(dividerStyle.Render(divider) + "\n")
 
 	// Runs list
-	if c.Error != nil {
+	if c.Error != nil && len(c.Runs) == 0 {
 		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
 		b.WriteString(errStyle.Render("Error loading") + "\n")
 	} else if len(c.Runs) == 0 {
@@ -265,6 +587,31 @@ func (c Card) renderContent() string {
 	return b.String()
 }
 
+// filterLabel describes the card's branch/workflow filter, if any.
+func (c Card) filterLabel() string {
+	var parts []string
+	if c.Repo.Branch != "" {
+		parts = append(parts, c.Repo.Branch)
+	}
+	if c.Repo.Workflow != "" {
+		parts = append(parts, c.Repo.Workflow)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(parts, " · ") + "]"
+}
+
+func (c Card) staleLabel() string {
+	if c.FetchedAt.IsZero() {
+		return "stale"
+	}
+	if time.Since(c.FetchedAt) < 24*time.Hour {
+		return "stale since " + c.FetchedAt.Format("15:04")
+	}
+	return "stale since " + c.FetchedAt.Format("Jan 2 15:04")
+}
+
 func (c Card) renderRunLine(run github.WorkflowRun, selected bool) string {
 	icon := runStatusIcon(run.RunStatus())
 
@@ -330,20 +677,18 @@ func (c Card) statusDot() string {
 }
 
 func runStatusIcon(status github.RunStatus) string {
+	color := lipgloss.Color("241")
 	switch status {
 	case github.StatusSuccess:
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render("[ok]")
+		color = lipgloss.Color("42")
 	case github.StatusFailure:
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render("[X]")
+		color = lipgloss.Color("196")
 	case github.StatusInProgress:
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render("[~]")
+		color = lipgloss.Color("214")
 	case github.StatusPending:
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("247")).Render("[?]")
-	case github.StatusCancelled:
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("[-]")
-	default:
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("[.]")
+		color = lipgloss.Color("247")
 	}
+	return lipgloss.NewStyle().Foreground(color).Render(status.Icon())
 }
 
 func formatTimeAgo(t time.Time) string {
@@ -429,7 +774,14 @@ func (c Card) renderRunDetail() string {
 	// Help line
 	b.WriteString("\n")
 	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-	b.WriteString(helpStyle.Render("esc: back"))
+	if c.RerunNote != "" {
+		b.WriteString(helpStyle.Render(c.RerunNote) + "\n")
+	}
+	help := "esc: back"
+	if run.Status == "completed" {
+		help += "  R/F: re-run all/failed"
+	}
+	b.WriteString(helpStyle.Render(help))
 
 	return b.String()
 }
@@ -453,8 +805,17 @@ func (c Card) renderJobLine(job github.Job, selected bool) string {
 
 	line := fmt.Sprintf("%s %s %s", icon, name, duration)
 
+	marker := ""
+	workflow := job.WorkflowName
+	if workflow == "" && c.DetailRun != nil {
+		workflow = c.DetailRun.WorkflowName
+	}
+	if c.DetailFlaky[flaky.Key(workflow, job.Name)] {
+		marker = " " + lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Render("flaky")
+	}
+
 	if selected {
-		return lipgloss.NewStyle().Bold(true).Reverse(true).Render(line)
+		return lipgloss.NewStyle().Bold(true).Reverse(true).Render(line) + marker
 	}
-	return line
+	return line + marker
 }
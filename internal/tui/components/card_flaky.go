@@ -0,0 +1,151 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/flaky"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/history"
+	"github.com/thesimpledev/ghflow/internal/poll"
+)
+
+// FlakyFetchedMsg delivers the flaky jobs found in a repo's history.
+type FlakyFetchedMsg struct {
+	Repo  string
+	Jobs  []flaky.Job
+	Error error
+}
+
+// fetchJobs loads the jobs of run. When record is set, the earlier
+// attempts of a re-run workflow are fetched too, so a failure that passed
+// on retry is on record, and everything is written to store before flaky
+// jobs are looked up. A daemon that polls for us records them itself.
+func fetchJobs(ctx context.Context, repo config.Repo, run github.WorkflowRun, store *history.Store, record bool) tea.Cmd {
+	return func() tea.Msg {
+		key := fmt.Sprintf("jobs:%s:%d", repo.FullName(), run.ID)
+		val, gen, err := fetches.Do(ctx, key, func(ctx context.Context) (any, error) {
+			if store == nil || !record {
+				return github.FetchRunJobs(ctx, repo.Owner, repo.Name, run.ID)
+			}
+			jobs, earlier, err := poll.RunJobs(ctx, repo, run)
+			if err != nil {
+				return nil, err
+			}
+			_ = store.RecordRuns(repo.FullName(), []github.WorkflowRun{run})
+			_ = store.RecordJobs(repo.FullName(), append(earlier, jobs...))
+			return jobs, nil
+		})
+		jobs, _ := val.([]github.Job)
+
+		var flakyKeys map[string]bool
+		if err == nil && store != nil {
+			flakyKeys = map[string]bool{}
+			found, _ := detectFlaky(store, repo.FullName())
+			for _, job := range found {
+				flakyKeys[flaky.Key(job.Workflow, job.Name)] = true
+			}
+		}
+
+		return JobsFetchedMsg{
+			Repo:  repo.FullName(),
+			RunID: run.ID,
+			Jobs:  jobs,
+			Flaky: flakyKeys,
+			Error: err,
+			Key:   key,
+			Gen:   gen,
+		}
+	}
+}
+
+// fetchFlaky lists the flaky jobs recorded for repo. It only reads local
+// history, so it works offline.
+func fetchFlaky(repo config.Repo, store *history.Store) tea.Cmd {
+	return func() tea.Msg {
+		if store == nil {
+			return FlakyFetchedMsg{Repo: repo.FullName(), Error: fmt.Errorf("history is unavailable")}
+		}
+		jobs, err := detectFlaky(store, repo.FullName())
+		return FlakyFetchedMsg{Repo: repo.FullName(), Jobs: jobs, Error: err}
+	}
+}
+
+func detectFlaky(store *history.Store, repo string) ([]flaky.Job, error) {
+	records, err := store.Runs(repo)
+	if err != nil {
+		return nil, err
+	}
+	return flaky.Detect(records), nil
+}
+
+func (c Card) renderFlaky() string {
+	var b strings.Builder
+
+	width := c.Width
+	if width < 20 {
+		width = 20
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	b.WriteString(headerStyle.Render("Flaky jobs: "+c.Repo.FullName()) + "\n")
+
+	switch {
+	case c.LoadingFlaky:
+		loadStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		b.WriteString(loadStyle.Render("Loading...") + "\n")
+	case c.FlakyErr != nil:
+		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		b.WriteString(errStyle.Render("Error loading history") + "\n")
+	case len(c.FlakyJobs) == 0:
+		b.WriteString(dimStyle.Render("No flaky jobs recorded") + "\n")
+	default:
+		b.WriteString(dimStyle.Render("commits flaked, job, last seen") + "\n")
+
+		dividerWidth := width - 4
+		if dividerWidth < 1 {
+			dividerWidth = 1
+		}
+		b.WriteString(dimStyle.Render(strings.Repeat("─", dividerWidth)) + "\n")
+
+		// Header, count line, divider, blank line and help line
+		available := c.Height - 7
+		if available < 1 {
+			available = 1
+		}
+		countStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
+		for i, job := range c.FlakyJobs {
+			if i == available-1 && len(c.FlakyJobs) > available {
+				b.WriteString(dimStyle.Render(fmt.Sprintf("+%d more", len(c.FlakyJobs)-i)) + "\n")
+				break
+			}
+			name := job.Name
+			if job.Workflow != "" {
+				name = job.Workflow + " / " + name
+			}
+			maxNameLen := width - 22
+			if maxNameLen < 6 {
+				maxNameLen = 6
+			}
+			if len(name) > maxNameLen {
+				name = name[:maxNameLen-3] + "..."
+			}
+			b.WriteString(fmt.Sprintf("%s %s %s\n",
+				countStyle.Render(fmt.Sprintf("%3dx", job.Occurrences)),
+				name,
+				dimStyle.Render(formatTimeAgo(job.LastSeen))))
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("esc: back"))
+
+	return b.String()
+}
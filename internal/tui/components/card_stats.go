@@ -0,0 +1,173 @@
+package components
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/history"
+	"github.com/thesimpledev/ghflow/internal/stats"
+)
+
+// DefaultStatsRuns is how many default-branch runs the stats view covers.
+const DefaultStatsRuns = 100
+
+// StatsFetchedMsg delivers a health report to the card for Repo.
+type StatsFetchedMsg struct {
+	Repo   string
+	Report *stats.Report
+	Error  error
+	Key    string
+	Gen    uint64
+}
+
+// fetchStats computes a report over the default branch's recent runs.
+// When the API can't be reached, or local is set because a daemon keeps
+// the history, it uses the local history.
+func fetchStats(ctx context.Context, repo config.Repo, store *history.Store, limit, days int, local bool) tea.Cmd {
+	owner, name := repo.Owner, repo.Name
+	return func() tea.Msg {
+		key := "stats:" + repo.FullName()
+		val, gen, err := fetches.Do(ctx, key, func(ctx context.Context) (any, error) {
+			var since time.Time
+			if days > 0 {
+				since = time.Now().AddDate(0, 0, -days)
+			}
+
+			// Without the API the default branch is unknown; a card
+			// filtered to a branch is the best guess
+			branch := repo.Branch
+			var runs []github.WorkflowRun
+			var err error
+			if !local {
+				branch, err = github.FetchDefaultBranch(ctx, owner, name)
+				if err == nil {
+					filter := github.RunFilter{Branch: branch, Since: since}
+					runs, err = github.FetchRuns(ctx, owner, name, filter, limit)
+				}
+			}
+			if local || err != nil {
+				if store == nil {
+					return nil, cmp.Or(err, errors.New("history is unavailable"))
+				}
+				runs, err = historyRuns(store, repo.FullName(), branch, since, limit)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			report := stats.Compute(branch, runs)
+			return &report, nil
+		})
+		report, _ := val.(*stats.Report)
+		return StatsFetchedMsg{
+			Repo:   repo.FullName(),
+			Report: report,
+			Error:  err,
+			Key:    key,
+			Gen:    gen,
+		}
+	}
+}
+
+// historyRuns returns recorded runs for branch (any branch if empty),
+// newest first.
+func historyRuns(store *history.Store, repo, branch string, since time.Time, limit int) ([]github.WorkflowRun, error) {
+	records, err := store.Runs(repo)
+	if err != nil {
+		return nil, err
+	}
+	var runs []github.WorkflowRun
+	for _, rec := range records {
+		if branch != "" && rec.Run.HeadBranch != branch {
+			continue
+		}
+		if rec.Run.CreatedAt.Before(since) {
+			continue
+		}
+		runs = append(runs, rec.Run)
+		if len(runs) >= limit {
+			break
+		}
+	}
+	return runs, nil
+}
+
+func (c Card) renderStats() string {
+	var b strings.Builder
+
+	width := c.Width
+	if width < 20 {
+		width = 20
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	b.WriteString(headerStyle.Render("Stats: "+c.Repo.FullName()) + "\n")
+
+	switch {
+	case c.LoadingStats:
+		loadStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		b.WriteString(loadStyle.Render("Loading...") + "\n")
+	case c.StatsErr != nil:
+		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		b.WriteString(errStyle.Render("Error loading stats") + "\n")
+	case c.Stats == nil || c.Stats.Runs == 0:
+		b.WriteString(dimStyle.Render("No runs") + "\n")
+	default:
+		r := c.Stats
+		branch := r.Branch
+		if branch == "" {
+			branch = "all branches"
+		}
+		b.WriteString(dimStyle.Render(fmt.Sprintf("%s, last %d runs", branch, r.Runs)) + "\n")
+
+		dividerWidth := width - 4
+		if dividerWidth < 1 {
+			dividerWidth = 1
+		}
+		b.WriteString(dimStyle.Render(strings.Repeat("─", dividerWidth)) + "\n")
+
+		rateStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+		if r.SuccessRate < 0.8 {
+			rateStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		}
+		b.WriteString(fmt.Sprintf("Success  %s (%d/%d)\n",
+			rateStyle.Render(fmt.Sprintf("%.0f%%", r.SuccessRate*100)),
+			r.Succeeded, r.Succeeded+r.Failed))
+
+		mttr := "-"
+		if r.Recoveries > 0 {
+			mttr = fmt.Sprintf("%s (%d recoveries)", formatDuration(r.MTTR), r.Recoveries)
+		}
+		b.WriteString("MTTR     " + mttr + "\n")
+		b.WriteString(fmt.Sprintf("Queue    p50 %s  p95 %s\n", formatDuration(r.QueueMedian), formatDuration(r.QueueP95)))
+
+		b.WriteString(dimStyle.Render("Duration p50 / p95:") + "\n")
+		for _, wf := range r.Workflows {
+			name := wf.Name
+			maxNameLen := width - 24
+			if maxNameLen < 6 {
+				maxNameLen = 6
+			}
+			if len(name) > maxNameLen {
+				name = name[:maxNameLen-3] + "..."
+			}
+			b.WriteString(fmt.Sprintf("  %s %s / %s\n", name, formatDuration(wf.DurationMedian), formatDuration(wf.DurationP95)))
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("esc: back"))
+
+	return b.String()
+}
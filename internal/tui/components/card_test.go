@@ -0,0 +1,36 @@
+package components
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+func TestApplyRunOutOfOrder(t *testing.T) {
+	created := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	running := github.WorkflowRun{ID: 1, Status: "in_progress", CreatedAt: created, UpdatedAt: created.Add(time.Minute)}
+	finished := running
+	finished.Status, finished.Conclusion = "completed", "success"
+	finished.UpdatedAt = created.Add(5 * time.Minute)
+
+	card := NewCard(context.Background(), config.Repo{Owner: "acme", Name: "app"})
+	card, changed := card.applyRun(finished)
+	if !changed || card.Status != github.StatusSuccess {
+		t.Fatalf("after finished run: changed = %v, status = %s", changed, card.Status)
+	}
+
+	// The in_progress delivery arrives late and must not win
+	card, changed = card.applyRun(running)
+	if changed || card.Status != github.StatusSuccess || len(card.Runs) != 1 {
+		t.Errorf("after late delivery: changed = %v, status = %s, %d runs", changed, card.Status, len(card.Runs))
+	}
+
+	newer := github.WorkflowRun{ID: 2, Status: "queued", CreatedAt: created.Add(time.Hour), UpdatedAt: created.Add(time.Hour)}
+	card, changed = card.applyRun(newer)
+	if !changed || len(card.Runs) != 2 || card.Runs[0].ID != 2 {
+		t.Errorf("after new run: changed = %v, runs = %v", changed, card.Runs)
+	}
+}
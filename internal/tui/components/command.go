@@ -23,6 +23,10 @@ const (
 	CmdSave
 	CmdLoad
 	CmdNew
+	CmdFilter
+	CmdExport
+	CmdFocus
+	CmdScan
 )
 
 type Command struct {
@@ -102,7 +106,7 @@ func (c CommandInput) Update(msg tea.Msg) (CommandInput, tea.Cmd) {
 				return c, nil
 			}
 			// Execute command
-			cmd := c.parseCommand()
+			cmd := ParseCommand(c.Input)
 			c.Focused = false
 			c.Input = ""
 			c.Suggestions = nil
@@ -124,7 +128,7 @@ func (c CommandInput) Update(msg tea.Msg) (CommandInput, tea.Cmd) {
 				}
 
 				isRepo := strings.HasSuffix(suggestion, " [repo]")
-				isAddPath := strings.HasPrefix(suggestion, "/add ")
+				isAddPath := strings.HasPrefix(suggestion, "/add ") || strings.HasPrefix(suggestion, "/scan ")
 				// Strip [repo] marker
 				suggestion = strings.TrimSuffix(suggestion, " [repo]")
 
@@ -196,11 +200,15 @@ func (c *CommandInput) updateSuggestions() {
 		hint string
 	}
 	commands := []cmdInfo{
-		{"add", "<path>"},
+		{"add", "<path or owner/name>"},
 		{"remove", "<repo>"},
 		{"save", "<name>"},
 		{"load", "<profile>"},
 		{"new", ""},
+		{"filter", "<branch=name workflow=name>"},
+		{"export", "<file>"},
+		{"focus", "<repo>"},
+		{"scan", "<dir> [--depth N]"},
 		{"refresh", ""},
 		{"quit", ""},
 		{"q", ""},
@@ -221,16 +229,20 @@ func (c *CommandInput) updateSuggestions() {
 		// Complete argument
 		switch cmd {
 		case "add":
-			c.Suggestions = c.completePath(arg)
-		case "remove":
-			c.Suggestions = c.completeRepo(arg)
+			c.Suggestions = c.completePath(cmd, arg)
+		case "scan":
+			if !strings.Contains(arg, " ") {
+				c.Suggestions = c.completePath(cmd, arg)
+			}
+		case "remove", "focus":
+			c.Suggestions = c.completeRepo(cmd, arg)
 		case "load":
 			c.Suggestions = c.completeProfile(arg)
 		}
 	}
 }
 
-func (c *CommandInput) completePath(partial string) []string {
+func (c *CommandInput) completePath(cmd, partial string) []string {
 	var suggestions []string
 
 	// Start from last path if available, otherwise current directory
@@ -275,7 +287,7 @@ func (c *CommandInput) completePath(partial string) []string {
 
 		// Check if it's a git repo
 		isRepo := repo.IsGitRepo(fullPath)
-		suggestion := "/add " + fullPath
+		suggestion := "/" + cmd + " " + fullPath
 		if isRepo {
 			suggestion += " [repo]"
 		}
@@ -289,13 +301,13 @@ func (c *CommandInput) completePath(partial string) []string {
 	return suggestions
 }
 
-func (c *CommandInput) completeRepo(partial string) []string {
+func (c *CommandInput) completeRepo(cmd, partial string) []string {
 	var suggestions []string
 
 	for _, r := range c.repos {
 		name := r.Owner + "/" + r.Name
 		if partial == "" || strings.Contains(strings.ToLower(name), strings.ToLower(partial)) {
-			suggestions = append(suggestions, "/remove "+name)
+			suggestions = append(suggestions, "/"+cmd+" "+name)
 		}
 		if len(suggestions) >= 10 {
 			break
@@ -337,8 +349,10 @@ func (c CommandInput) acceptSuggestion() CommandInput {
 	return c
 }
 
-func (c CommandInput) parseCommand() Command {
-	input := strings.TrimPrefix(c.Input, "/")
+// ParseCommand parses a slash command as typed, with or without the
+// leading slash.
+func ParseCommand(input string) Command {
+	input = strings.TrimPrefix(strings.TrimSpace(input), "/")
 	parts := strings.SplitN(input, " ", 2)
 	cmd := parts[0]
 	arg := ""
@@ -357,6 +371,14 @@ func (c CommandInput) parseCommand() Command {
 		return Command{Type: CmdLoad, Arg: arg}
 	case "new":
 		return Command{Type: CmdNew}
+	case "filter":
+		return Command{Type: CmdFilter, Arg: arg}
+	case "export":
+		return Command{Type: CmdExport, Arg: arg}
+	case "focus":
+		return Command{Type: CmdFocus, Arg: arg}
+	case "scan":
+		return Command{Type: CmdScan, Arg: arg}
 	case "refresh":
 		return Command{Type: CmdRefresh}
 	case "quit", "q":
@@ -373,7 +395,7 @@ func (c CommandInput) View() string {
 	inputStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(lipgloss.Color("62")).
-		Width(c.Width - 2).
+		Width(c.Width-2).
 		Padding(0, 1)
 
 	prompt := "> "
@@ -1,13 +1,21 @@
 package components
 
 import (
+	"context"
+	"errors"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/thesimpledev/ghflow/internal/cache"
 	"github.com/thesimpledev/ghflow/internal/config"
 	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/history"
+	"github.com/thesimpledev/ghflow/internal/hooks"
+	"github.com/thesimpledev/ghflow/internal/notify"
+	"github.com/thesimpledev/ghflow/internal/poll"
 )
 
 const (
@@ -23,24 +31,114 @@ const (
 )
 
 type Grid struct {
-	Cards       []Card
-	Cursor      int
-	State       GridState
-	Width       int
-	Height      int
+	Cards  []Card
+	Cursor int
+	State  GridState
+	Width  int
+	Height int
+
+	// parent is cancelled when the app quits. ctx scopes batched requests
+	// to the current set of repos and is replaced whenever that set changes.
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	pollMin time.Duration
+	pollMax time.Duration
+
+	history *history.Store
+	cache   *cache.Cache
+
+	statsRuns int
+	statsDays int
+
+	notifier *notify.Dispatcher
+	hooks    *hooks.Runner
+
+	// remote polls on the grid's behalf when set
+	remote Remote
 }
 
+// Remote is a daemon that polls for the grid and delivers the results as
+// RemoteStatusMsg.
+type Remote interface {
+	Watch(ctx context.Context, repos []config.Repo) error
+	Refresh(ctx context.Context) error
+	Rerun(ctx context.Context, repo string, runID int64, failedOnly bool) error
+}
+
+// RemoteStatusMsg delivers the runs a Remote polled. Cards pick the
+// result for their repo and filter.
+type RemoteStatusMsg struct {
+	Results []poll.Result
+}
+
+// CardStatusMsg delivers a card's runs. Repo is the card's stable identity
+// (owner/name) and Gen is the request generation handed out by the fetch
+// scheduler; results older than what the card already shows, or fetched
+// for a filter the card no longer has, are dropped.
 type CardStatusMsg struct {
-	Index  int
-	Status github.RunStatus
-	Runs   []github.WorkflowRun
-	Error  error
+	Repo     string
+	Branch   string // Filter the runs were fetched with
+	Workflow string
+	Status   github.RunStatus
+	Runs     []github.WorkflowRun
+	Error    error
+	Gen      uint64
+	Remote   bool // Polled, and saved, by the daemon
+}
+
+// CardStatusBatchMsg carries the result of refreshing several cards at
+// once.
+type CardStatusBatchMsg struct {
+	Statuses []CardStatusMsg
+}
+
+// HistoryLoadedMsg delivers the runs recorded for a card's repo.
+type HistoryLoadedMsg struct {
+	Repo config.Repo
+	Runs []github.WorkflowRun
+}
+
+// NotifyFailedMsg reports notifications about run transitions that
+// could not be sent.
+type NotifyFailedMsg struct {
+	Err error
+}
+
+// RerunMsg reports how re-running RunID, asked for from a card's run
+// detail view, went.
+type RerunMsg struct {
+	Repo  string
+	RunID int64
+	Err   error
+}
+
+// RunEventMsg pushes a single run or job update (e.g. from a webhook)
+// into the card for Repo.
+type RunEventMsg struct {
+	Repo string
+	Run  *github.WorkflowRun
+	Job  *github.Job
 }
 
-func NewGrid(repos []config.Repo) Grid {
+const (
+	// Cards with a queued or running workflow poll at the minimum
+	// interval. Idle cards start at idlePollStart and back off
+	// exponentially up to the maximum while nothing changes.
+	DefaultPollMin = 5 * time.Second
+	DefaultPollMax = 5 * time.Minute
+	idlePollStart  = 30 * time.Second
+)
+
+// fetches is the process-wide request scheduler, so rebuilding the grid
+// doesn't reset the concurrency limit or lose track of in-flight requests.
+var fetches = poll.Requests
+
+func NewGrid(ctx context.Context, repos []config.Repo) Grid {
 	cards := make([]Card, len(repos))
 	for i, repo := range repos {
-		cards[i] = NewCard(repo)
+		cards[i] = NewCard(ctx, repo)
 	}
 
 	// Set first card as selected
@@ -48,13 +146,158 @@ func NewGrid(repos []config.Repo) Grid {
 		cards[0] = cards[0].SetState(CardSelected)
 	}
 
+	setCtx, cancel := context.WithCancel(ctx)
 	return Grid{
-		Cards:  cards,
-		State:  GridNavigating,
-		Cursor: 0,
+		Cards:   cards,
+		State:   GridNavigating,
+		Cursor:  0,
+		parent:  ctx,
+		ctx:     setCtx,
+		cancel:  cancel,
+		pollMin: DefaultPollMin,
+		pollMax: DefaultPollMax,
+
+		statsRuns: DefaultStatsRuns,
 	}
 }
 
+// SetStatsWindow limits the stats view to the last runs runs and, when
+// days is positive, to runs created in the last days days.
+func (g Grid) SetStatsWindow(runs, days int) Grid {
+	if runs <= 0 {
+		runs = DefaultStatsRuns
+	}
+	g.statsRuns = runs
+	g.statsDays = days
+	return g
+}
+
+// SetCache saves every successful refresh to c, and fills cards that have
+// nothing to show yet with their cached snapshot, marked as stale.
+func (g Grid) SetCache(c *cache.Cache) Grid {
+	g.cache = c
+	for i := range g.Cards {
+		g.Cards[i] = g.Cards[i].loadCache(c)
+	}
+	return g
+}
+
+// SetHistory records everything the grid fetches into store. LoadHistory
+// fills cards from it.
+func (g Grid) SetHistory(store *history.Store) Grid {
+	g.history = store
+	return g
+}
+
+// LoadHistory fills cards that have nothing to show yet with their last
+// known runs. The files are read off the UI goroutine.
+func (g Grid) LoadHistory() tea.Cmd {
+	if g.history == nil {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, card := range g.Cards {
+		if len(card.Runs) == 0 {
+			cmds = append(cmds, loadHistory(g.history, card.Repo))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// SetNotifier sends run transitions seen by the grid to d.
+func (g Grid) SetNotifier(d *notify.Dispatcher) Grid {
+	g.notifier = d
+	return g
+}
+
+// SetHooks runs r's commands on run events seen by the grid.
+func (g Grid) SetHooks(r *hooks.Runner) Grid {
+	g.hooks = r
+	return g
+}
+
+// SetRemote hands polling to r, which also takes over notifications,
+// hooks and recording history. A nil r goes back to polling directly.
+func (g Grid) SetRemote(r Remote) Grid {
+	g.remote = r
+	if r != nil {
+		g.notifier = nil
+		g.hooks = nil
+	}
+	return g
+}
+
+// SetPollIntervals bounds how often each card polls. Zero values keep
+// the defaults.
+func (g Grid) SetPollIntervals(minInterval, maxInterval time.Duration) Grid {
+	if minInterval <= 0 {
+		minInterval = DefaultPollMin
+	}
+	if maxInterval <= 0 {
+		maxInterval = DefaultPollMax
+	}
+	if maxInterval < minInterval {
+		maxInterval = minInterval
+	}
+	g.pollMin = minInterval
+	g.pollMax = maxInterval
+	return g
+}
+
+// SetRepos rebuilds the card list for repos. Cards for repos that were
+// already on the grid keep their runs, and the cursor stays on the same
+// repo when it is still present.
+func (g Grid) SetRepos(repos []config.Repo) Grid {
+	existing := make(map[string]Card, len(g.Cards))
+	for _, card := range g.Cards {
+		existing[card.Repo.FullName()] = card
+	}
+
+	selected := ""
+	if r := g.SelectedRepo(); r != nil {
+		selected = r.FullName()
+	}
+
+	cards := make([]Card, len(repos))
+	cursor := 0
+	for i, repo := range repos {
+		if card, ok := existing[repo.FullName()]; ok {
+			if card.Repo.Branch != repo.Branch || card.Repo.Workflow != repo.Workflow {
+				// Filter changed; what the card shows no longer applies,
+				// and neither does anything still in flight
+				card.Runs = []github.WorkflowRun{}
+				card.Status = github.StatusUnknown
+				card.Stale = false
+				card.Gen = fetches.Next()
+			}
+			card.Repo = repo
+			cards[i] = card.SetState(CardNormal)
+			delete(existing, repo.FullName())
+		} else {
+			cards[i] = NewCard(g.parent, repo).loadCache(g.cache)
+		}
+		if repo.FullName() == selected {
+			cursor = i
+		}
+	}
+	if len(cards) > 0 {
+		cards[cursor] = cards[cursor].SetState(CardSelected)
+	}
+
+	// Abort whatever is still in flight for cards that left the grid, and
+	// for batches covering the old set of repos
+	for _, card := range existing {
+		card.Close()
+	}
+	g.cancel()
+	g.ctx, g.cancel = context.WithCancel(g.parent)
+
+	g.Cards = cards
+	g.Cursor = cursor
+	g.State = GridNavigating
+	return g.SetSize(g.Width, g.Height)
+}
+
 func (g Grid) SetSize(width, height int) Grid {
 	g.Width = width
 	g.Height = height
@@ -71,26 +314,54 @@ func (g Grid) SetSize(width, height int) Grid {
 }
 
 func (g Grid) Init() tea.Cmd {
-	var cmds []tea.Cmd
+	return tea.Batch(g.LoadHistory(), g.RefreshAll())
+}
+
+// cardIndex returns the index of the card for repo (owner/name), or -1.
+func (g Grid) cardIndex(repo string) int {
 	for i, card := range g.Cards {
-		cmds = append(cmds, fetchCardStatus(i, card.Repo.Owner, card.Repo.Name))
+		if strings.EqualFold(card.Repo.FullName(), repo) {
+			return i
+		}
 	}
-	return tea.Batch(cmds...)
+	return -1
 }
 
-func fetchCardStatus(index int, owner, name string) tea.Cmd {
+// Close cancels every request the grid has in flight.
+func (g Grid) Close() {
+	for _, card := range g.Cards {
+		card.Close()
+	}
+	g.cancel()
+}
+
+func newCardStatusMsg(repo config.Repo, runs []github.WorkflowRun, err error) CardStatusMsg {
+	runs = poll.FilterRuns(repo, runs)
+	status := github.StatusUnknown
+	if len(runs) > 0 {
+		status = runs[0].RunStatus()
+	}
+	return CardStatusMsg{
+		Repo:     repo.FullName(),
+		Branch:   repo.Branch,
+		Workflow: repo.Workflow,
+		Status:   status,
+		Runs:     runs,
+		Error:    err,
+	}
+}
+
+// fetchStatuses fetches repos the way every other poller does, batching
+// what it can.
+func fetchStatuses(ctx context.Context, repos []config.Repo) tea.Cmd {
 	return func() tea.Msg {
-		runs, err := github.FetchWorkflowRuns(owner, name, 5)
-		status := github.StatusUnknown
-		if len(runs) > 0 {
-			status = runs[0].RunStatus()
-		}
-		return CardStatusMsg{
-			Index:  index,
-			Status: status,
-			Runs:   runs,
-			Error:  err,
+		var msg CardStatusBatchMsg
+		for _, r := range poll.FetchAll(ctx, repos) {
+			status := newCardStatusMsg(r.Repo, r.Runs, r.Err)
+			status.Gen = r.Gen
+			msg.Statuses = append(msg.Statuses, status)
 		}
+		return msg
 	}
 }
 
@@ -99,28 +370,154 @@ func (g Grid) Update(msg tea.Msg) (Grid, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case CardStatusMsg:
-		if msg.Index < len(g.Cards) {
-			g.Cards[msg.Index].Status = msg.Status
-			g.Cards[msg.Index].Runs = msg.Runs
-			g.Cards[msg.Index].Error = msg.Error
+		if errors.Is(msg.Error, context.Canceled) {
+			// Cancelled on purpose; keep whatever the card already shows
+			return g, nil
+		}
+		i := g.cardIndex(msg.Repo)
+		if i < 0 || msg.Gen < g.Cards[i].Gen {
+			// Card was removed, or a newer result already landed
+			return g, nil
+		}
+		if g.Cards[i].Repo.Branch != msg.Branch || g.Cards[i].Repo.Workflow != msg.Workflow {
+			// Fetched before the card's filter changed
+			return g, nil
+		}
+		now := time.Now()
+		card := &g.Cards[i]
+		prevRuns := card.Runs
+		card.Gen = msg.Gen
+		card.Error = msg.Error
+		if msg.Error == nil {
+			if card.live {
+				// Runs loaded from disk may be days old; only compare
+				// against what this session fetched
+				cmds = append(cmds, g.transitions(card.Repo, notify.Detect(msg.Repo, prevRuns, msg.Runs)))
+			}
+			cmds = append(cmds, g.queued(card.Repo, msg.Runs, now))
+			card.Status = msg.Status
+			card.Runs = msg.Runs
+			card.FetchedAt = now
+			card.Stale = false
+			card.live = true
+			if !msg.Remote {
+				cmds = append(cmds,
+					recordRuns(g.history, msg.Repo, msg.Runs),
+					recordRunJobs(g.ctx, g.history, card.Repo, msg.Runs),
+					saveSnapshot(g.cache, card.Repo, msg.Runs, now),
+				)
+			}
+		} else if len(card.Runs) > 0 {
+			// Keep showing the last good state rather than an error
+			card.Stale = true
+		}
+		*card = card.reschedule(prevRuns, now, g.pollMin, g.pollMax)
+		return g, tea.Batch(cmds...)
+
+	case RemoteStatusMsg:
+		// Newer than anything fetched so far, including a fallback fetch
+		// made while the daemon was unreachable
+		gen := fetches.Next()
+		for _, r := range msg.Results {
+			for _, card := range g.Cards {
+				if card.Repo.FullName() != r.Repo.FullName() || card.Repo.Branch != r.Repo.Branch || card.Repo.Workflow != r.Repo.Workflow {
+					continue
+				}
+				status := newCardStatusMsg(card.Repo, r.Runs, r.Err)
+				status.Gen = gen
+				status.Remote = true
+				var cmd tea.Cmd
+				g, cmd = g.Update(status)
+				cmds = append(cmds, cmd)
+				break
+			}
+		}
+		return g, tea.Batch(cmds...)
+
+	case CardStatusBatchMsg:
+		for _, status := range msg.Statuses {
+			var cmd tea.Cmd
+			g, cmd = g.Update(status)
+			cmds = append(cmds, cmd)
+		}
+		return g, tea.Batch(cmds...)
+
+	case HistoryLoadedMsg:
+		i := g.cardIndex(msg.Repo.FullName())
+		if i >= 0 && g.Cards[i].Repo == msg.Repo {
+			g.Cards[i] = g.Cards[i].applyHistory(msg.Runs)
+		}
+		return g, nil
+
+	case RunEventMsg:
+		if i := g.cardIndex(msg.Repo); i >= 0 {
+			if msg.Run != nil {
+				prevRuns := g.Cards[i].Runs
+				var changed bool
+				g.Cards[i], changed = g.Cards[i].applyRun(*msg.Run)
+				if changed {
+					// A poll already in flight is older than this
+					g.Cards[i].Gen = fetches.Next()
+					if g.Cards[i].live {
+						cmds = append(cmds, g.transitions(g.Cards[i].Repo, notify.Detect(msg.Repo, prevRuns, g.Cards[i].Runs)))
+					}
+				}
+				cmds = append(cmds, recordRuns(g.history, msg.Repo, []github.WorkflowRun{*msg.Run}))
+			}
+			if msg.Job != nil {
+				cmds = append(cmds, g.jobEvent(g.Cards[i], *msg.Job))
+				g.Cards[i] = g.Cards[i].applyJob(*msg.Job)
+				cmds = append(cmds, recordJobs(g.history, msg.Repo, []github.Job{*msg.Job}))
+			}
+		}
+		return g, tea.Batch(cmds...)
+
+	case RerunMsg:
+		if i := g.cardIndex(msg.Repo); i >= 0 {
+			g.Cards[i], _ = g.Cards[i].Update(msg)
+			if msg.Err == nil && g.remote == nil {
+				// Pick up the new attempt; a daemon polls after a re-run
+				// by itself
+				g.Cards[i].nextPoll = time.Now()
+			}
 		}
 		return g, nil
 
 	case JobsFetchedMsg:
-		// Forward to focused card
-		if g.Cursor < len(g.Cards) {
+		if fetches.Superseded(msg.Key, msg.Gen) {
+			return g, nil
+		}
+		// Deliver to the card that asked, wherever the cursor is now
+		if i := g.cardIndex(msg.Repo); i >= 0 {
 			var cmd tea.Cmd
-			g.Cards[g.Cursor], cmd = g.Cards[g.Cursor].Update(msg)
+			g.Cards[i], cmd = g.Cards[i].Update(msg)
 			cmds = append(cmds, cmd)
 		}
 		return g, tea.Batch(cmds...)
 
+	case StatsFetchedMsg:
+		if fetches.Superseded(msg.Key, msg.Gen) {
+			return g, nil
+		}
+		if i := g.cardIndex(msg.Repo); i >= 0 {
+			g.Cards[i], _ = g.Cards[i].Update(msg)
+		}
+		return g, nil
+
+	case FlakyFetchedMsg:
+		if i := g.cardIndex(msg.Repo); i >= 0 {
+			g.Cards[i], _ = g.Cards[i].Update(msg)
+		}
+		return g, nil
+
 	case tea.KeyMsg:
 		if g.State == GridCardFocused {
 			// Check card state before update for Esc handling
 			cardStateBeforeUpdate := CardNormal
+			rerunPending := false
 			if g.Cursor < len(g.Cards) {
 				cardStateBeforeUpdate = g.Cards[g.Cursor].State
+				rerunPending = g.Cards[g.Cursor].RerunPending
 			}
 
 			// Forward to focused card
@@ -128,6 +525,21 @@ func (g Grid) Update(msg tea.Msg) (Grid, tea.Cmd) {
 				var cmd tea.Cmd
 				g.Cards[g.Cursor], cmd = g.Cards[g.Cursor].Update(msg)
 				cmds = append(cmds, cmd)
+
+				card := g.Cards[g.Cursor]
+				if cardStateBeforeUpdate != card.State {
+					switch card.State {
+					case CardRunDetail:
+						cmds = append(cmds, fetchJobs(card.ctx, card.Repo, *card.DetailRun, g.history, g.remote == nil))
+					case CardStats:
+						cmds = append(cmds, fetchStats(card.ctx, card.Repo, g.history, g.statsRuns, g.statsDays, g.remote != nil))
+					case CardFlaky:
+						cmds = append(cmds, fetchFlaky(card.Repo, g.history))
+					}
+				}
+				if card.RerunPending && !rerunPending {
+					cmds = append(cmds, rerun(card.ctx, g.remote, card.Repo, *card.DetailRun, msg.String() == "F"))
+				}
 			}
 
 			// Handle escape to unfocus - only if card WAS in CardFocused state (not CardRunDetail)
@@ -209,12 +621,166 @@ func (g Grid) moveCursor(dx, dy int) Grid {
 	return g
 }
 
+// recordRuns writes runs to the history store off the UI goroutine.
+// History is best effort: a failed write never affects the dashboard.
+func recordRuns(store *history.Store, repo string, runs []github.WorkflowRun) tea.Cmd {
+	if store == nil || len(runs) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		_ = store.RecordRuns(repo, runs)
+		return nil
+	}
+}
+
+// transitions sends run transitions to the notifier and hooks off the
+// UI goroutine. Like history, both are best effort.
+func (g Grid) transitions(repo config.Repo, events []notify.Event) tea.Cmd {
+	if (g.notifier == nil && g.hooks == nil) || len(events) == 0 {
+		return nil
+	}
+	d, r, ctx := g.notifier, g.hooks, g.parent
+	return func() tea.Msg {
+		if r != nil {
+			r.Transitions(ctx, repo, events)
+		}
+		if d != nil {
+			if err := d.Send(ctx, events); err != nil {
+				return NotifyFailedMsg{Err: err}
+			}
+		}
+		return nil
+	}
+}
+
+// queued fires queue_timeout hooks for runs stuck in the queue.
+func (g Grid) queued(repo config.Repo, runs []github.WorkflowRun, now time.Time) tea.Cmd {
+	if g.hooks == nil {
+		return nil
+	}
+	r, ctx := g.hooks, g.parent
+	return func() tea.Msg {
+		r.Queued(ctx, repo, runs, now)
+		return nil
+	}
+}
+
+// jobEvent fires job_failed hooks for a pushed job update.
+func (g Grid) jobEvent(card Card, job github.Job) tea.Cmd {
+	if g.hooks == nil {
+		return nil
+	}
+	var run github.WorkflowRun
+	for _, r := range card.Runs {
+		if r.ID == job.RunID {
+			run = r
+			break
+		}
+	}
+	r, ctx, repo := g.hooks, g.parent, card.Repo
+	return func() tea.Msg {
+		r.Job(ctx, repo, run, job)
+		return nil
+	}
+}
+
+func recordJobs(store *history.Store, repo string, jobs []github.Job) tea.Cmd {
+	if store == nil || len(jobs) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		_ = store.RecordJobs(repo, jobs)
+		return nil
+	}
+}
+
+// recordRunJobs records the jobs of failed and re-run runs for flaky
+// detection, without waiting for the user to open them.
+func recordRunJobs(ctx context.Context, store *history.Store, repo config.Repo, runs []github.WorkflowRun) tea.Cmd {
+	if store == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		_ = poll.RecordJobs(ctx, store, repo, runs)
+		return nil
+	}
+}
+
+func saveSnapshot(c *cache.Cache, repo config.Repo, runs []github.WorkflowRun, fetchedAt time.Time) tea.Cmd {
+	if c == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		_ = c.Save(repo, runs, fetchedAt)
+		return nil
+	}
+}
+
+// PollDue refreshes, in one batched request, every card whose next poll
+// time has passed.
+func (g Grid) PollDue(now time.Time) (Grid, tea.Cmd) {
+	if github.Offline() || g.remote != nil {
+		return g, nil
+	}
+	var due []config.Repo
+	for i := range g.Cards {
+		card := &g.Cards[i]
+		if now.Before(card.nextPoll) {
+			continue
+		}
+		due = append(due, card.Repo)
+		// Hold off re-polling while the request is in flight; the
+		// result reschedules the card properly
+		card.nextPoll = now.Add(max(card.pollInterval, g.pollMin))
+	}
+	if len(due) == 0 {
+		return g, nil
+	}
+	return g, fetchStatuses(g.ctx, due)
+}
+
+// rerun re-runs run, or only its failed jobs. With a remote the request
+// goes through its daemon, which polls for the new attempt afterwards.
+func rerun(ctx context.Context, r Remote, repo config.Repo, run github.WorkflowRun, failedOnly bool) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if r != nil {
+			err = r.Rerun(ctx, repo.FullName(), run.ID, failedOnly)
+		} else {
+			err = github.RerunRun(ctx, repo.Owner, repo.Name, run.ID, failedOnly)
+		}
+		return RerunMsg{Repo: repo.FullName(), RunID: run.ID, Err: err}
+	}
+}
+
+// RefreshAll refreshes every card, batching what it can.
 func (g Grid) RefreshAll() tea.Cmd {
-	var cmds []tea.Cmd
+	if len(g.Cards) == 0 || github.Offline() {
+		return nil
+	}
+	repos := make([]config.Repo, len(g.Cards))
 	for i, card := range g.Cards {
-		cmds = append(cmds, fetchCardStatus(i, card.Repo.Owner, card.Repo.Name))
+		repos[i] = card.Repo
+	}
+	if g.remote != nil {
+		return refreshRemote(g.ctx, g.remote, repos)
+	}
+	return fetchStatuses(g.ctx, repos)
+}
+
+// refreshRemote has the remote poll repos now. If it can't be reached the
+// repos are fetched directly this once.
+func refreshRemote(ctx context.Context, r Remote, repos []config.Repo) tea.Cmd {
+	return func() tea.Msg {
+		err := r.Watch(ctx, repos)
+		if err == nil {
+			err = r.Refresh(ctx)
+		}
+		if err == nil {
+			return nil
+		}
+		return fetchStatuses(ctx, repos)()
 	}
-	return tea.Batch(cmds...)
 }
 
 func (g Grid) SelectedRepo() *config.Repo {
@@ -224,6 +790,29 @@ func (g Grid) SelectedRepo() *config.Repo {
 	return nil
 }
 
+// Focus moves the cursor to the card for repo (owner/name) and opens it,
+// as if the user had navigated there and pressed enter. It reports
+// whether the repo has a card.
+func (g Grid) Focus(repo string) (Grid, bool) {
+	i := -1
+	for j, card := range g.Cards {
+		if strings.EqualFold(card.Repo.FullName(), repo) {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return g, false
+	}
+	if g.Cursor < len(g.Cards) {
+		g.Cards[g.Cursor] = g.Cards[g.Cursor].SetState(CardNormal)
+	}
+	g.Cursor = i
+	g.State = GridCardFocused
+	g.Cards[i] = g.Cards[i].SetState(CardFocused)
+	return g, true
+}
+
 func (g Grid) SelectedCard() *Card {
 	if g.Cursor < len(g.Cards) {
 		return &g.Cards[g.Cursor]
@@ -258,8 +847,8 @@ func (g Grid) View() string {
 				emptyStyle := lipgloss.NewStyle().
 					Border(lipgloss.RoundedBorder()).
 					BorderForeground(lipgloss.Color("236")).
-					Width(cardWidth - 2).
-					Height(cardHeight - 2).
+					Width(cardWidth-2).
+					Height(cardHeight-2).
 					Align(lipgloss.Center, lipgloss.Center).
 					Foreground(lipgloss.Color("241"))
 				rowCards = append(rowCards, emptyStyle.Render(""))
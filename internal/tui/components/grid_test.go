@@ -0,0 +1,39 @@
+package components
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+func TestFilterChangeDropsStalePoll(t *testing.T) {
+	repo := config.Repo{Owner: "acme", Name: "app"}
+	g := NewGrid(context.Background(), []config.Repo{repo})
+
+	// A poll for the unfiltered card is handed out, then the card is
+	// filtered before the result lands
+	gen := fetches.Next()
+	filtered := repo
+	filtered.Branch = "dev"
+	g = g.SetRepos([]config.Repo{filtered})
+
+	run := github.WorkflowRun{ID: 1, HeadBranch: "main", Status: "completed", Conclusion: "failure", CreatedAt: time.Now()}
+	stale := newCardStatusMsg(repo, []github.WorkflowRun{run}, nil)
+	stale.Gen = gen
+	g, _ = g.Update(stale)
+	if len(g.Cards[0].Runs) != 0 {
+		t.Fatalf("stale poll landed on the filtered card: %v", g.Cards[0].Runs)
+	}
+
+	// A poll made for the new filter does land
+	run.HeadBranch = "dev"
+	fresh := newCardStatusMsg(filtered, []github.WorkflowRun{run}, nil)
+	fresh.Gen = fetches.Next()
+	g, _ = g.Update(fresh)
+	if len(g.Cards[0].Runs) != 1 {
+		t.Errorf("fresh poll was dropped")
+	}
+}
@@ -0,0 +1,357 @@
+package components
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/history"
+	"github.com/thesimpledev/ghflow/internal/inbox"
+)
+
+const (
+	// inboxFetchSize is how many runs per repo the inbox looks through.
+	inboxFetchSize = 100
+
+	// InboxRefreshInterval is how often the inbox rechecks every repo
+	// while it's open.
+	InboxRefreshInterval = 2 * time.Minute
+
+	// inboxSnooze is how long "z" hides an item.
+	inboxSnooze = time.Hour
+)
+
+// InboxFetchedMsg delivers the runs needing attention across all repos.
+type InboxFetchedMsg struct {
+	Items  []inbox.Item
+	Failed []string // Repos that could not be checked
+	Gen    uint64
+}
+
+// Inbox lists failed runs across every saved profile, newest first.
+type Inbox struct {
+	Items       []inbox.Item // Not acknowledged or snoozed
+	Failed      []string
+	Err         error // Acknowledging or snoozing failed
+	Cursor      int
+	ScrollPos   int
+	Width       int
+	Height      int
+	Loading     bool
+	RefreshedAt time.Time
+	Detail      *Card // Run detail drilled into, if any
+
+	all     []inbox.Item
+	gen     uint64
+	ctx     context.Context
+	state   *inbox.State
+	history *history.Store
+	days    int
+	local   bool // Read history only; a daemon keeps it
+}
+
+func NewInbox(ctx context.Context, state *inbox.State, store *history.Store, days int) Inbox {
+	if days <= 0 {
+		days = inbox.DefaultDays
+	}
+	return Inbox{ctx: ctx, state: state, history: store, days: days}
+}
+
+func (in Inbox) SetSize(width, height int) Inbox {
+	in.Width = width
+	in.Height = height
+	if in.Detail != nil {
+		d := in.Detail.SetSize(width, height)
+		in.Detail = &d
+	}
+	return in
+}
+
+// SetRemote has the inbox read the history r's daemon records instead of
+// asking the API. A nil r goes back to the API.
+func (in Inbox) SetRemote(r Remote) Inbox {
+	in.local = r != nil
+	return in
+}
+
+// Count is the number of items shown in the inbox.
+func (in Inbox) Count() int {
+	return len(in.Items)
+}
+
+// Refresh rechecks repos for failures.
+func (in Inbox) Refresh(repos []config.Repo) (Inbox, tea.Cmd) {
+	in.gen++
+	in.Loading = true
+	return in, fetchInbox(in.ctx, repos, in.history, in.days, in.gen, in.local)
+}
+
+func fetchInbox(ctx context.Context, repos []config.Repo, store *history.Store, days int, gen uint64, local bool) tea.Cmd {
+	return func() tea.Msg {
+		since := time.Now().AddDate(0, 0, -days)
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		msg := InboxFetchedMsg{Gen: gen}
+		for _, repo := range repos {
+			wg.Add(1)
+			go func(repo config.Repo) {
+				defer wg.Done()
+				runs, err := inboxRuns(ctx, repo, store, since, local)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					msg.Failed = append(msg.Failed, repo.FullName())
+					return
+				}
+				for _, run := range inbox.Failures(runs) {
+					msg.Items = append(msg.Items, inbox.Item{Repo: repo, Run: run})
+				}
+			}(repo)
+		}
+		wg.Wait()
+
+		inbox.Sort(msg.Items)
+		return msg
+	}
+}
+
+// inboxRuns returns repo's runs since the given time, from local history
+// when local is set or the API can't be reached.
+func inboxRuns(ctx context.Context, repo config.Repo, store *history.Store, since time.Time, local bool) ([]github.WorkflowRun, error) {
+	var runs []github.WorkflowRun
+	var err error
+	if !local {
+		key := fmt.Sprintf("inbox:%s@%s", repo.FullName(), repo.Branch)
+		var val any
+		val, _, err = fetches.Do(ctx, key, func(ctx context.Context) (any, error) {
+			filter := github.RunFilter{Branch: repo.Branch, Since: since}
+			runs, err := github.FetchRuns(ctx, repo.Owner, repo.Name, filter, inboxFetchSize)
+			if err == nil && store != nil {
+				_ = store.RecordRuns(repo.FullName(), runs)
+			}
+			return runs, err
+		})
+		runs, _ = val.([]github.WorkflowRun)
+	}
+	if local || err != nil {
+		if store == nil {
+			return nil, cmp.Or(err, errors.New("history is unavailable"))
+		}
+		runs, err = historyRuns(store, repo.FullName(), repo.Branch, since, inboxFetchSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var matched []github.WorkflowRun
+	for _, run := range runs {
+		if repo.MatchesRun(run.HeadBranch, run.WorkflowName) {
+			matched = append(matched, run)
+		}
+	}
+	return matched, nil
+}
+
+// visible drops acknowledged and snoozed items from in.all.
+func (in Inbox) visible() Inbox {
+	now := time.Now()
+	in.Items = nil
+	for _, item := range in.all {
+		if in.state != nil && in.state.Hidden(item, now) {
+			continue
+		}
+		in.Items = append(in.Items, item)
+	}
+	if in.Cursor >= len(in.Items) {
+		in.Cursor = max(len(in.Items)-1, 0)
+	}
+	if in.ScrollPos > in.Cursor {
+		in.ScrollPos = in.Cursor
+	}
+	return in
+}
+
+func (in Inbox) Update(msg tea.Msg) (Inbox, tea.Cmd) {
+	switch msg := msg.(type) {
+	case InboxFetchedMsg:
+		if msg.Gen != in.gen {
+			return in, nil
+		}
+		in.Loading = false
+		in.RefreshedAt = time.Now()
+		in.all = msg.Items
+		in.Failed = msg.Failed
+		return in.visible(), nil
+
+	case JobsFetchedMsg:
+		if in.Detail == nil || in.Detail.Repo.FullName() != msg.Repo || fetches.Superseded(msg.Key, msg.Gen) {
+			return in, nil
+		}
+		d, cmd := in.Detail.Update(msg)
+		in.Detail = &d
+		return in, cmd
+
+	case tea.KeyMsg:
+		if in.Detail != nil {
+			d, cmd := in.Detail.Update(msg)
+			if d.State != CardRunDetail {
+				// Esc left the run; go back to the list
+				d.Close()
+				in.Detail = nil
+				return in, cmd
+			}
+			in.Detail = &d
+			return in, cmd
+		}
+
+		switch msg.String() {
+		case "j", "down":
+			if in.Cursor < len(in.Items)-1 {
+				in.Cursor++
+				if in.Cursor >= in.ScrollPos+in.visibleRows() {
+					in.ScrollPos++
+				}
+			}
+		case "k", "up":
+			if in.Cursor > 0 {
+				in.Cursor--
+				if in.Cursor < in.ScrollPos {
+					in.ScrollPos--
+				}
+			}
+		case "enter":
+			if item, ok := in.selected(); ok {
+				d := NewCard(in.ctx, item.Repo).SetSize(in.Width, in.Height)
+				run := item.Run
+				d.State = CardRunDetail
+				d.DetailRun = &run
+				d.LoadingJobs = true
+				in.Detail = &d
+				return in, fetchJobs(d.ctx, d.Repo, run, in.history, !in.local)
+			}
+		case "a":
+			if item, ok := in.selected(); ok && in.state != nil {
+				in.Err = in.state.Ack(item)
+				return in.visible(), nil
+			}
+		case "z":
+			if item, ok := in.selected(); ok && in.state != nil {
+				in.Err = in.state.Snooze(item, time.Now().Add(inboxSnooze))
+				return in.visible(), nil
+			}
+		}
+	}
+	return in, nil
+}
+
+func (in Inbox) selected() (inbox.Item, bool) {
+	if in.Cursor < 0 || in.Cursor >= len(in.Items) {
+		return inbox.Item{}, false
+	}
+	return in.Items[in.Cursor], true
+}
+
+func (in Inbox) visibleRows() int {
+	// Border, header, divider
+	rows := in.Height - 5
+	if rows < 1 {
+		return 1
+	}
+	return rows
+}
+
+func (in Inbox) View() string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.ThickBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Width(in.Width-2).
+		Height(in.Height-2).
+		Padding(0, 1)
+
+	if in.Detail != nil {
+		return style.Render(in.Detail.renderRunDetail())
+	}
+	return style.Render(in.renderList())
+}
+
+func (in Inbox) renderList() string {
+	var b strings.Builder
+
+	width := in.Width
+	if width < 20 {
+		width = 20
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	header := headerStyle.Render(fmt.Sprintf("Inbox (%d)", len(in.Items)))
+	switch {
+	case in.Err != nil:
+		header += " " + lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render("could not save: "+in.Err.Error())
+	case in.Loading:
+		header += " " + lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render("refreshing...")
+	case len(in.Failed) > 0:
+		header += " " + lipgloss.NewStyle().Foreground(lipgloss.Color("214")).
+			Render(fmt.Sprintf("could not check %s", strings.Join(in.Failed, ", ")))
+	}
+	b.WriteString(header + "\n")
+
+	dividerWidth := width - 4
+	if dividerWidth < 1 {
+		dividerWidth = 1
+	}
+	b.WriteString(dimStyle.Render(strings.Repeat("─", dividerWidth)) + "\n")
+
+	if len(in.Items) == 0 {
+		if in.Loading && in.RefreshedAt.IsZero() {
+			b.WriteString(dimStyle.Render("Loading...") + "\n")
+		} else {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("Nothing failed in the last %d days", in.days)) + "\n")
+		}
+		return b.String()
+	}
+
+	end := min(in.ScrollPos+in.visibleRows(), len(in.Items))
+	for i := in.ScrollPos; i < end; i++ {
+		b.WriteString(in.renderItem(in.Items[i], i == in.Cursor, width) + "\n")
+	}
+	return b.String()
+}
+
+func (in Inbox) renderItem(item inbox.Item, selected bool, width int) string {
+	run := item.Run
+	conclusion := strings.ReplaceAll(run.Conclusion, "_", " ")
+	actor := ""
+	if run.Actor.Login != "" {
+		actor = "@" + run.Actor.Login
+	}
+
+	line := fmt.Sprintf("%s  %s #%d  %s  %s  %s  %s",
+		item.Repo.FullName(), run.WorkflowName, run.RunNumber,
+		run.HeadBranch, actor, formatTimeAgo(run.CreatedAt), conclusion)
+	maxLen := width - 6
+	if maxLen < 10 {
+		maxLen = 10
+	}
+	if len(line) > maxLen {
+		line = line[:maxLen-3] + "..."
+	}
+
+	icon := runStatusIcon(github.StatusFailure)
+	if selected {
+		return icon + " " + lipgloss.NewStyle().Bold(true).Reverse(true).Render(line)
+	}
+	return icon + " " + line
+}
@@ -0,0 +1,183 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/repo"
+)
+
+// ScanDoneMsg delivers the repos /scan found under Root.
+type ScanDoneMsg struct {
+	Root  string
+	Repos []repo.RepoInfo
+	Err   error
+}
+
+// ScanConfirmedMsg carries the repos picked from the checklist. It's sent
+// with no repos when the checklist is dismissed.
+type ScanConfirmedMsg struct {
+	Repos []repo.RepoInfo
+}
+
+type scanItem struct {
+	info    repo.RepoInfo
+	checked bool
+	present bool // Already on the dashboard
+}
+
+// ScanList is a checklist of the repos found by /scan.
+type ScanList struct {
+	Root      string
+	Cursor    int
+	ScrollPos int
+	Width     int
+	Height    int
+
+	items []scanItem
+}
+
+// NewScanList lists found, with repos already on the dashboard marked and
+// not selectable.
+func NewScanList(root string, found []repo.RepoInfo, current []config.Repo) ScanList {
+	have := map[string]bool{}
+	for _, r := range current {
+		have[strings.ToLower(r.FullName())] = true
+	}
+	items := make([]scanItem, len(found))
+	for i, info := range found {
+		items[i] = scanItem{info: info, present: have[strings.ToLower(info.Owner+"/"+info.Name)]}
+	}
+	return ScanList{Root: root, items: items}
+}
+
+func (s ScanList) SetSize(width, height int) ScanList {
+	s.Width = width
+	s.Height = height
+	return s
+}
+
+// checked returns the repos ticked on the list.
+func (s ScanList) checked() []repo.RepoInfo {
+	var repos []repo.RepoInfo
+	for _, item := range s.items {
+		if item.checked {
+			repos = append(repos, item.info)
+		}
+	}
+	return repos
+}
+
+func (s ScanList) Update(msg tea.Msg) (ScanList, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return s, nil
+	}
+	switch key.String() {
+	case "j", "down":
+		if s.Cursor < len(s.items)-1 {
+			s.Cursor++
+			if s.Cursor >= s.ScrollPos+s.visibleRows() {
+				s.ScrollPos++
+			}
+		}
+	case "k", "up":
+		if s.Cursor > 0 {
+			s.Cursor--
+			if s.Cursor < s.ScrollPos {
+				s.ScrollPos--
+			}
+		}
+	case " ", "x":
+		if s.Cursor < len(s.items) && !s.items[s.Cursor].present {
+			s.items[s.Cursor].checked = !s.items[s.Cursor].checked
+		}
+	case "a":
+		// Tick everything, or untick everything if it's all ticked
+		all := true
+		for _, item := range s.items {
+			if !item.present && !item.checked {
+				all = false
+				break
+			}
+		}
+		for i := range s.items {
+			if !s.items[i].present {
+				s.items[i].checked = !all
+			}
+		}
+	case "enter":
+		repos := s.checked()
+		return s, func() tea.Msg { return ScanConfirmedMsg{Repos: repos} }
+	case "esc":
+		return s, func() tea.Msg { return ScanConfirmedMsg{} }
+	}
+	return s, nil
+}
+
+func (s ScanList) visibleRows() int {
+	// Border, header, divider
+	rows := s.Height - 5
+	if rows < 1 {
+		return 1
+	}
+	return rows
+}
+
+func (s ScanList) View() string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.ThickBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Width(s.Width-2).
+		Height(s.Height-2).
+		Padding(0, 1)
+
+	var b strings.Builder
+	width := max(s.Width, 20)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	header := lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("Found %d repos under %s", len(s.items), s.Root))
+	if n := len(s.checked()); n > 0 {
+		header += " " + lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render(fmt.Sprintf("%d selected", n))
+	}
+	b.WriteString(header + "\n")
+	b.WriteString(dimStyle.Render(strings.Repeat("─", max(width-4, 1))) + "\n")
+
+	end := min(s.ScrollPos+s.visibleRows(), len(s.items))
+	for i := s.ScrollPos; i < end; i++ {
+		b.WriteString(s.renderItem(s.items[i], i == s.Cursor, width) + "\n")
+	}
+
+	return style.Render(b.String())
+}
+
+func (s ScanList) renderItem(item scanItem, selected bool, width int) string {
+	box := "[ ]"
+	switch {
+	case item.present:
+		box = "[-]"
+	case item.checked:
+		box = "[x]"
+	}
+
+	line := fmt.Sprintf("%s/%s  %s", item.info.Owner, item.info.Name, item.info.Path)
+	if item.present {
+		line += "  (on the dashboard)"
+	}
+	maxLen := max(width-10, 10)
+	if len(line) > maxLen {
+		line = line[:maxLen-3] + "..."
+	}
+
+	if selected {
+		return box + " " + lipgloss.NewStyle().Bold(true).Reverse(true).Render(line)
+	}
+	if item.present {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(box + " " + line)
+	}
+	return box + " " + line
+}
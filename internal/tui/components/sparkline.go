@@ -0,0 +1,98 @@
+package components
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// statusColor is the colour used for a run status across the card.
+func statusColor(status github.RunStatus) lipgloss.Color {
+	switch status {
+	case github.StatusSuccess:
+		return lipgloss.Color("42")
+	case github.StatusFailure:
+		return lipgloss.Color("196")
+	case github.StatusInProgress:
+		return lipgloss.Color("214")
+	case github.StatusPending:
+		return lipgloss.Color("247")
+	default:
+		return lipgloss.Color("241")
+	}
+}
+
+// outcomeSparkline renders the last n runs as one bar each, oldest on the
+// left. Failures are tall and successes short so the trend reads even
+// without colour.
+func outcomeSparkline(runs []github.WorkflowRun, n int) string {
+	runs = lastN(runs, n)
+	var b strings.Builder
+	for i := len(runs) - 1; i >= 0; i-- {
+		status := runs[i].RunStatus()
+		bar := sparkLevels[0]
+		switch status {
+		case github.StatusSuccess:
+			bar = sparkLevels[2]
+		case github.StatusFailure:
+			bar = sparkLevels[7]
+		case github.StatusInProgress, github.StatusPending:
+			bar = sparkLevels[4]
+		}
+		b.WriteString(lipgloss.NewStyle().Foreground(statusColor(status)).Render(string(bar)))
+	}
+	return b.String()
+}
+
+// durationSparkline renders how long each of the last n completed runs
+// took, scaled to the slowest, oldest on the left.
+func durationSparkline(runs []github.WorkflowRun, n int) string {
+	var completed []github.WorkflowRun
+	var longest time.Duration
+	for _, run := range runs {
+		if run.Status != "completed" {
+			continue
+		}
+		completed = append(completed, run)
+		if d := runTime(run); d > longest {
+			longest = d
+		}
+	}
+	completed = lastN(completed, n)
+	if longest <= 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i := len(completed) - 1; i >= 0; i-- {
+		run := completed[i]
+		level := int(runTime(run) * time.Duration(len(sparkLevels)-1) / longest)
+		style := lipgloss.NewStyle().Foreground(statusColor(run.RunStatus()))
+		b.WriteString(style.Render(string(sparkLevels[level])))
+	}
+	return b.String()
+}
+
+// lastN returns the n most recent runs (runs are newest first).
+func lastN(runs []github.WorkflowRun, n int) []github.WorkflowRun {
+	if len(runs) > n {
+		return runs[:n]
+	}
+	return runs
+}
+
+func runTime(run github.WorkflowRun) time.Duration {
+	start := run.RunStartedAt
+	if start.IsZero() {
+		start = run.CreatedAt
+	}
+	if run.UpdatedAt.Before(start) {
+		return 0
+	}
+	return run.UpdatedAt.Sub(start)
+}
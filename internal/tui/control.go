@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/thesimpledev/ghflow/internal/control"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/rpc"
+)
+
+// controlMsg is a request from the control socket. Update answers it on
+// reply, which is buffered so it never blocks the UI.
+type controlMsg struct {
+	method string
+	params json.RawMessage
+	reply  chan controlReply
+}
+
+type controlReply struct {
+	result any
+	err    error
+}
+
+// ServeControl answers the control socket on ln until ctx is done. Each
+// request goes through p's Update, so it sees and changes the dashboard
+// exactly as a key press would.
+func ServeControl(ctx context.Context, p *tea.Program, ln net.Listener) error {
+	srv := rpc.NewServer()
+	for _, method := range []string{control.MethodCommand, control.MethodStatus} {
+		srv.Handle(method, func(ctx context.Context, _ *rpc.Conn, params json.RawMessage) (any, error) {
+			return ask(ctx, p, method, params)
+		})
+	}
+	srv.Handle(control.MethodRun, func(ctx context.Context, _ *rpc.Conn, params json.RawMessage) (any, error) {
+		result, err := ask(ctx, p, control.MethodRun, params)
+		if err != nil {
+			return nil, err
+		}
+		// Jobs are fetched here rather than in Update, which mustn't block
+		status := result.(control.RunStatus)
+		if !github.Offline() {
+			owner, name, _ := strings.Cut(status.Repo, "/")
+			jobs, err := github.FetchRunJobs(ctx, owner, name, status.Run.ID)
+			if err != nil {
+				return nil, err
+			}
+			status.Jobs = jobs
+		}
+		return status, nil
+	})
+	return srv.Serve(ctx, ln)
+}
+
+func ask(ctx context.Context, p *tea.Program, method string, params json.RawMessage) (any, error) {
+	reply := make(chan controlReply, 1)
+	p.Send(controlMsg{method: method, params: params, reply: reply})
+	select {
+	case r := <-reply:
+		return r.result, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// control answers msg from the dashboard's current state.
+func (a App) control(msg controlMsg) (App, tea.Cmd, controlReply) {
+	switch msg.method {
+	case control.MethodCommand:
+		var params control.CommandParams
+		if err := rpc.DecodeParams(msg.params, &params); err != nil {
+			return a, nil, controlReply{err: err}
+		}
+		var cmd tea.Cmd
+		var err error
+		a.dashboard, cmd, err = a.dashboard.Control(params.Command)
+		return a, cmd, controlReply{result: struct{}{}, err: err}
+
+	case control.MethodStatus:
+		return a, nil, controlReply{result: a.dashboard.CardStatuses()}
+
+	case control.MethodRun:
+		var params control.RunParams
+		if err := rpc.DecodeParams(msg.params, &params); err != nil {
+			return a, nil, controlReply{err: err}
+		}
+		status, err := a.dashboard.FindRun(params.Repo, params.RunID)
+		return a, nil, controlReply{result: status, err: err}
+	}
+	return a, nil, controlReply{err: rpc.Errorf(rpc.CodeMethodNotFound, "unknown method %s", msg.method)}
+}
@@ -1,13 +1,29 @@
 package views
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/thesimpledev/ghflow/internal/cache"
 	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/control"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/history"
+	"github.com/thesimpledev/ghflow/internal/hooks"
+	"github.com/thesimpledev/ghflow/internal/inbox"
+	"github.com/thesimpledev/ghflow/internal/notify"
+	"github.com/thesimpledev/ghflow/internal/poll"
 	"github.com/thesimpledev/ghflow/internal/repo"
+	"github.com/thesimpledev/ghflow/internal/report"
 	"github.com/thesimpledev/ghflow/internal/tui/components"
 )
 
@@ -16,39 +32,111 @@ type InputMode int
 const (
 	ModeGrid InputMode = iota
 	ModeCommand
+	ModeInbox
+	ModeScan
 )
 
 type DashboardModel struct {
+	ctx          context.Context
 	config       *config.Config
 	grid         components.Grid
 	commandInput components.CommandInput
+	inbox        components.Inbox
+	scan         components.ScanList
 	mode         InputMode
 	width        int
 	height       int
 	err          error
+	notice       string // Shown in place of the help line until the next command
 	profileName  string // Current loaded profile name
+
+	inboxCheckedAt time.Time
 }
 
 // Messages
 type RefreshMsg struct{}
+
+// PollMsg asks the grid to refresh cards whose poll interval has elapsed.
+type PollMsg time.Time
+
+// DaemonLostMsg says the daemon polling for the dashboard went away.
+type DaemonLostMsg struct{}
+
+// ExportedMsg reports the outcome of /export.
+type ExportedMsg struct {
+	Path string
+	Err  error
+}
+
+// RepoAddedMsg delivers a repo added by owner/name once the API has
+// confirmed it exists.
 type RepoAddedMsg struct {
 	Repo config.Repo
+	Err  error
 }
 type RepoRemovedMsg struct {
 	Owner string
 	Name  string
 }
 
-func NewDashboardModel(cfg *config.Config) DashboardModel {
+func NewDashboardModel(ctx context.Context, cfg *config.Config) DashboardModel {
+	grid := components.NewGrid(ctx, cfg.Repos).SetPollIntervals(
+		time.Duration(cfg.PollMinInterval)*time.Second,
+		time.Duration(cfg.PollMaxInterval)*time.Second,
+	).SetStatsWindow(cfg.StatsRuns, cfg.StatsDays)
+
+	// Without cache or history the dashboard still works, it just starts
+	// empty. The cache goes first: its snapshots know when they were taken.
+	snapshots, err := cache.Open()
+	if err == nil {
+		grid = grid.SetCache(snapshots)
+	}
+	store, historyErr := history.Open()
+	if historyErr == nil {
+		grid = grid.SetHistory(store)
+	} else if err == nil {
+		err = historyErr
+	}
+	inboxState, inboxErr := inbox.Open()
+	if inboxErr != nil && err == nil {
+		err = inboxErr
+	}
+	if cfg.Notify != nil {
+		notifier, notifyErr := notify.NewDispatcher(*cfg.Notify)
+		if notifyErr == nil {
+			grid = grid.SetNotifier(notifier)
+		} else if err == nil {
+			err = notifyErr
+		}
+	}
+	if cfg.Hooks != nil {
+		runner, hooksErr := hooks.NewRunner(*cfg.Hooks)
+		if hooksErr == nil {
+			grid = grid.SetHooks(runner)
+		} else if err == nil {
+			err = hooksErr
+		}
+	}
+
 	return DashboardModel{
+		ctx:          ctx,
 		config:       cfg,
-		grid:         components.NewGrid(cfg.Repos),
+		grid:         grid,
 		commandInput: components.NewCommandInput(cfg.Repos),
+		inbox:        components.NewInbox(ctx, inboxState, store, cfg.InboxDays),
 		mode:         ModeGrid,
+		err:          err,
 		profileName:  cfg.ProfileName,
 	}
 }
 
+// SetRemote hands polling, notifications, hooks and history to a daemon.
+func (m DashboardModel) SetRemote(r components.Remote) DashboardModel {
+	m.grid = m.grid.SetRemote(r)
+	m.inbox = m.inbox.SetRemote(r)
+	return m
+}
+
 func (m DashboardModel) SetSize(width, height int) DashboardModel {
 	m.width = width
 	m.height = height
@@ -63,6 +151,8 @@ func (m DashboardModel) SetSize(width, height int) DashboardModel {
 	}
 
 	m.grid = m.grid.SetSize(width, gridHeight)
+	m.inbox = m.inbox.SetSize(width, gridHeight)
+	m.scan = m.scan.SetSize(width, gridHeight)
 	m.commandInput = m.commandInput.SetSize(width)
 
 	return m
@@ -72,6 +162,18 @@ func (m DashboardModel) Init() tea.Cmd {
 	return tea.Batch(m.grid.Init(), m.setWindowTitle())
 }
 
+// refreshInbox rechecks every repo in every profile for failures.
+func (m DashboardModel) refreshInbox(now time.Time) (DashboardModel, tea.Cmd) {
+	repos, err := m.config.AllRepos()
+	if err != nil {
+		m.err = err
+	}
+	m.inboxCheckedAt = now
+	var cmd tea.Cmd
+	m.inbox, cmd = m.inbox.Refresh(repos)
+	return m, cmd
+}
+
 func (m DashboardModel) setWindowTitle() tea.Cmd {
 	title := "ghflow"
 	if m.profileName != "" {
@@ -102,26 +204,125 @@ func (m DashboardModel) Update(msg tea.Msg) (DashboardModel, tea.Cmd) {
 				m.commandInput = m.commandInput.SetFocused(true)
 				return m, nil
 			}
+		case "i":
+			if m.mode == ModeGrid && m.grid.State == components.GridNavigating {
+				m.mode = ModeInbox
+				if time.Since(m.inboxCheckedAt) >= components.InboxRefreshInterval {
+					return m.refreshInbox(time.Now())
+				}
+				return m, nil
+			}
+		case "r":
+			if m.mode == ModeInbox && m.inbox.Detail == nil {
+				return m.refreshInbox(time.Now())
+			}
 		case "esc":
 			if m.mode == ModeCommand {
 				m.mode = ModeGrid
 				m.commandInput = m.commandInput.SetFocused(false)
 				return m, nil
 			}
+			if m.mode == ModeInbox && m.inbox.Detail == nil {
+				m.mode = ModeGrid
+				return m, nil
+			}
 		}
 
-	case components.CardStatusMsg:
+	case components.CardStatusMsg, components.CardStatusBatchMsg, components.RunEventMsg, components.RemoteStatusMsg, components.HistoryLoadedMsg,
+		components.JobsFetchedMsg, components.StatsFetchedMsg, components.FlakyFetchedMsg, components.RerunMsg:
 		var cmd tea.Cmd
 		m.grid, cmd = m.grid.Update(msg)
 		cmds = append(cmds, cmd)
+		if jobs, ok := msg.(components.JobsFetchedMsg); ok {
+			m.inbox, cmd = m.inbox.Update(jobs)
+			cmds = append(cmds, cmd)
+		}
 		return m, tea.Batch(cmds...)
 
+	case components.InboxFetchedMsg:
+		m.inbox, _ = m.inbox.Update(msg)
+		return m, nil
+
 	case components.ExecuteCommandMsg:
 		return m.handleCommand(msg.Cmd)
 
+	case RepoAddedMsg:
+		m.notice = ""
+		if msg.Err != nil {
+			m.err = msg.Err
+			return m, nil
+		}
+		m.config.AddRepo(msg.Repo)
+		if err := m.config.Save(); err != nil {
+			m.err = err
+		}
+		var load tea.Cmd
+		m, load = m.setRepos(m.config.Repos)
+		return m, tea.Batch(load, m.grid.RefreshAll())
+
+	case components.ScanDoneMsg:
+		m.notice = ""
+		switch {
+		case msg.Err != nil:
+			m.err = msg.Err
+		case len(msg.Repos) == 0:
+			m.notice = "no GitHub repos found under " + msg.Root
+		default:
+			m.scan = components.NewScanList(msg.Root, msg.Repos, m.config.Repos)
+			m = m.SetSize(m.width, m.height)
+			m.commandInput = m.commandInput.SetFocused(false)
+			m.mode = ModeScan
+		}
+		return m, nil
+
+	case components.ScanConfirmedMsg:
+		m.mode = ModeGrid
+		if len(msg.Repos) == 0 {
+			return m, nil
+		}
+		for _, info := range msg.Repos {
+			m.config.AddRepo(config.Repo{Path: info.Path, Owner: info.Owner, Name: info.Name})
+		}
+		if err := m.config.Save(); err != nil {
+			m.err = err
+		}
+		var load tea.Cmd
+		m, load = m.setRepos(m.config.Repos)
+		m.notice = fmt.Sprintf("added %d repos", len(msg.Repos))
+		return m, tea.Batch(load, m.grid.RefreshAll())
+
+	case components.NotifyFailedMsg:
+		m.err = msg.Err
+		return m, nil
+
+	case DaemonLostMsg:
+		m = m.SetRemote(nil)
+		m.err = errors.New("daemon stopped; polling directly, without notifications or hooks")
+		return m, m.grid.RefreshAll()
+
+	case ExportedMsg:
+		if msg.Err != nil {
+			m.err = msg.Err
+		} else {
+			m.notice = "exported to " + msg.Path
+		}
+		return m, nil
+
 	case RefreshMsg:
 		cmds = append(cmds, m.grid.RefreshAll())
 		return m, tea.Batch(cmds...)
+
+	case PollMsg:
+		var cmd tea.Cmd
+		m.grid, cmd = m.grid.PollDue(time.Time(msg))
+		cmds = append(cmds, cmd)
+		// The inbox checks every repo of every profile, so it only keeps
+		// itself current while someone's looking
+		if m.mode == ModeInbox && time.Time(msg).Sub(m.inboxCheckedAt) >= components.InboxRefreshInterval {
+			m, cmd = m.refreshInbox(time.Time(msg))
+			cmds = append(cmds, cmd)
+		}
+		return m, tea.Batch(cmds...)
 	}
 
 	// Route to focused component
@@ -138,6 +339,14 @@ func (m DashboardModel) Update(msg tea.Msg) (DashboardModel, tea.Cmd) {
 		var cmd tea.Cmd
 		m.grid, cmd = m.grid.Update(msg)
 		cmds = append(cmds, cmd)
+	case ModeInbox:
+		var cmd tea.Cmd
+		m.inbox, cmd = m.inbox.Update(msg)
+		cmds = append(cmds, cmd)
+	case ModeScan:
+		var cmd tea.Cmd
+		m.scan, cmd = m.scan.Update(msg)
+		cmds = append(cmds, cmd)
 	}
 
 	return m, tea.Batch(cmds...)
@@ -145,6 +354,7 @@ func (m DashboardModel) Update(msg tea.Msg) (DashboardModel, tea.Cmd) {
 
 func (m DashboardModel) handleCommand(cmd components.Command) (DashboardModel, tea.Cmd) {
 	m.err = nil
+	m.notice = ""
 
 	switch cmd.Type {
 	case components.CmdQuit:
@@ -156,8 +366,20 @@ func (m DashboardModel) handleCommand(cmd components.Command) (DashboardModel, t
 
 	case components.CmdAdd:
 		if cmd.Arg != "" {
-			info, err := repo.GetRepoInfo(cmd.Arg)
-			if err != nil || info == nil {
+			// A directory wins over an owner/name it happens to look like
+			if info, err := os.Stat(expandHome(cmd.Arg)); err != nil || !info.IsDir() {
+				if owner, name, ok := repo.ParseRef(cmd.Arg); ok {
+					m.notice = "looking up " + owner + "/" + name + "..."
+					m.mode = ModeGrid
+					return m, lookupRepo(m.ctx, owner, name)
+				}
+			}
+
+			info, err := repo.GetRepoInfo(expandHome(cmd.Arg))
+			if err == nil && info == nil {
+				err = fmt.Errorf("%s is not a GitHub checkout, owner/name or GitHub URL", cmd.Arg)
+			}
+			if err != nil {
 				m.err = err
 				m.mode = ModeGrid
 				return m, nil
@@ -174,12 +396,11 @@ func (m DashboardModel) handleCommand(cmd components.Command) (DashboardModel, t
 			}
 
 			// Rebuild grid with new repo
-			m.grid = components.NewGrid(m.config.Repos)
-			m.grid = m.grid.SetSize(m.width, m.height-6)
-			m.commandInput = m.commandInput.SetRepos(m.config.Repos)
+			var load tea.Cmd
+			m, load = m.setRepos(m.config.Repos)
 			m.commandInput = m.commandInput.SetLastPath(info.Path) // Remember for next /add
 			m.mode = ModeGrid
-			return m, m.grid.Init()
+			return m, tea.Batch(load, m.grid.RefreshAll())
 		}
 		m.mode = ModeGrid
 		return m, nil
@@ -195,9 +416,7 @@ func (m DashboardModel) handleCommand(cmd components.Command) (DashboardModel, t
 				}
 
 				// Rebuild grid without removed repo
-				m.grid = components.NewGrid(m.config.Repos)
-				m.grid = m.grid.SetSize(m.width, m.height-6)
-				m.commandInput = m.commandInput.SetRepos(m.config.Repos)
+				m, _ = m.setRepos(m.config.Repos)
 			}
 		}
 		m.mode = ModeGrid
@@ -236,11 +455,10 @@ func (m DashboardModel) handleCommand(cmd components.Command) (DashboardModel, t
 				m.profileName = cmd.Arg
 
 				// Rebuild grid with loaded repos
-				m.grid = components.NewGrid(m.config.Repos)
-				m.grid = m.grid.SetSize(m.width, m.height-6)
-				m.commandInput = m.commandInput.SetRepos(m.config.Repos)
+				var load tea.Cmd
+				m, load = m.setRepos(m.config.Repos)
 				m.mode = ModeGrid
-				return m, tea.Batch(m.grid.Init(), m.setWindowTitle())
+				return m, tea.Batch(load, m.grid.RefreshAll(), m.setWindowTitle())
 			}
 		}
 		m.mode = ModeGrid
@@ -256,18 +474,220 @@ func (m DashboardModel) handleCommand(cmd components.Command) (DashboardModel, t
 		m.profileName = "" // Clear profile name
 
 		// Rebuild empty grid
-		m.grid = components.NewGrid(m.config.Repos)
-		m.grid = m.grid.SetSize(m.width, m.height-6)
-		m.commandInput = m.commandInput.SetRepos(m.config.Repos)
+		m, _ = m.setRepos(m.config.Repos)
 		m.mode = ModeGrid
 		return m, m.setWindowTitle()
 
+	case components.CmdFilter:
+		m.mode = ModeGrid
+		selected := m.grid.SelectedRepo()
+		if selected == nil {
+			return m, nil
+		}
+		branch, workflow, err := parseFilter(cmd.Arg)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.config.SetFilter(selected.Owner, selected.Name, branch, workflow)
+		if err := m.config.Save(); err != nil {
+			m.err = err
+		}
+		var load tea.Cmd
+		m, load = m.setRepos(m.config.Repos)
+		return m, tea.Batch(load, m.grid.RefreshAll())
+
+	case components.CmdFocus:
+		m.mode = ModeGrid
+		if cmd.Arg == "" {
+			m.err = fmt.Errorf("usage: /focus <owner/name>")
+			return m, nil
+		}
+		var ok bool
+		if m.grid, ok = m.grid.Focus(cmd.Arg); !ok {
+			m.err = fmt.Errorf("%s is not on the dashboard", cmd.Arg)
+		}
+		return m, nil
+
+	case components.CmdScan:
+		m.mode = ModeGrid
+		dir, depth, err := parseScan(cmd.Arg)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.notice = "scanning " + dir + "..."
+		return m, scanRepos(dir, depth)
+
+	case components.CmdExport:
+		m.mode = ModeGrid
+		if cmd.Arg == "" {
+			m.err = fmt.Errorf("usage: /export <file.md|file.html|file.csv>")
+			return m, nil
+		}
+		return m, m.export(cmd.Arg)
+
 	default:
 		m.mode = ModeGrid
 		return m, nil
 	}
 }
 
+// Control runs a slash command from the control socket as if it had been
+// typed, and returns the error the help line shows for it.
+func (m DashboardModel) Control(input string) (DashboardModel, tea.Cmd, error) {
+	cmd := components.ParseCommand(input)
+	if cmd.Type == components.CmdUnknown {
+		return m, nil, fmt.Errorf("unknown command %q", input)
+	}
+	if m.mode == ModeCommand {
+		m.commandInput = m.commandInput.SetFocused(false)
+	}
+	m, teaCmd := m.handleCommand(cmd)
+	return m, teaCmd, m.err
+}
+
+// CardStatuses returns what each card shows, in grid order.
+func (m DashboardModel) CardStatuses() []control.CardStatus {
+	statuses := make([]control.CardStatus, len(m.grid.Cards))
+	for i, card := range m.grid.Cards {
+		s := control.CardStatus{
+			Repo:      card.Repo.FullName(),
+			Branch:    card.Repo.Branch,
+			Workflow:  card.Repo.Workflow,
+			Path:      card.Repo.Path,
+			Status:    card.Status,
+			FetchedAt: card.FetchedAt,
+			Stale:     card.Stale,
+			Selected:  i == m.grid.Cursor,
+		}
+		if len(card.Runs) > 0 {
+			s.Latest = &card.Runs[0]
+		}
+		if card.Error != nil {
+			s.Error = card.Error.Error()
+		}
+		statuses[i] = s
+	}
+	return statuses
+}
+
+// FindRun looks for run id among the runs the cards show, only on repo's
+// card when it's set.
+func (m DashboardModel) FindRun(repo string, id int64) (control.RunStatus, error) {
+	for _, card := range m.grid.Cards {
+		if repo != "" && !strings.EqualFold(card.Repo.FullName(), repo) {
+			continue
+		}
+		for _, run := range card.Runs {
+			if run.ID == id {
+				return control.RunStatus{Repo: card.Repo.FullName(), Run: run, Jobs: []github.Job{}}, nil
+			}
+		}
+	}
+	return control.RunStatus{}, fmt.Errorf("run %d is not on the dashboard", id)
+}
+
+// export writes a report of the cards as they are, in the format picked
+// by the file's extension. Only failed jobs are fetched.
+func (m DashboardModel) export(path string) tea.Cmd {
+	path = expandHome(path)
+	results := make([]poll.Result, len(m.grid.Cards))
+	for i, card := range m.grid.Cards {
+		results[i] = poll.Result{Repo: card.Repo, Runs: card.Runs, Err: card.Error}
+	}
+	ctx, title := m.ctx, report.Title(m.profileName)
+	return func() tea.Msg {
+		r := report.Build(ctx, title, results)
+		err := report.WriteFile(path, report.FormatFromPath(path), r)
+		return ExportedMsg{Path: path, Err: err}
+	}
+}
+
+// lookupRepo checks owner/name exists before it's added, since without a
+// checkout there's no remote to read it from.
+func lookupRepo(ctx context.Context, owner, name string) tea.Cmd {
+	return func() tea.Msg {
+		owner, name, err := github.LookupRepo(ctx, owner, name)
+		return RepoAddedMsg{Repo: config.Repo{Owner: owner, Name: name}, Err: err}
+	}
+}
+
+// scanRepos looks for repos under dir off the UI goroutine.
+func scanRepos(dir string, depth int) tea.Cmd {
+	return func() tea.Msg {
+		repos, err := repo.Scan(dir, depth)
+		return components.ScanDoneMsg{Root: dir, Repos: repos, Err: err}
+	}
+}
+
+// parseScan parses "/scan" arguments: a directory, then optionally
+// --depth N.
+func parseScan(arg string) (dir string, depth int, err error) {
+	depth = repo.DefaultScanDepth
+	// Everything before a trailing --depth is the directory, spaces and all
+	dir = strings.TrimSpace(arg)
+	if i := strings.LastIndex(dir, "--depth"); i >= 0 && (i == 0 || dir[i-1] == ' ') {
+		value, hasEquals := strings.CutPrefix(dir[i+len("--depth"):], "=")
+		if hasEquals || value == "" || strings.HasPrefix(value, " ") {
+			value = strings.TrimSpace(value)
+			if depth, err = strconv.Atoi(value); err != nil || depth < 1 {
+				return "", 0, fmt.Errorf("invalid depth %q: use a number of directories, 1 or more", value)
+			}
+			dir = strings.TrimSpace(dir[:i])
+		}
+	}
+	if dir == "" {
+		return "", 0, fmt.Errorf("usage: /scan <dir> [--depth N]")
+	}
+	return expandHome(dir), depth, nil
+}
+
+// expandHome replaces a leading ~/ with the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if path == "~" {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home
+		}
+	}
+	return path
+}
+
+// parseFilter parses "/filter" arguments: branch=NAME and/or
+// workflow=NAME. Workflow names may contain spaces; a value runs until the
+// next key. No arguments clears the filter.
+func parseFilter(arg string) (branch, workflow string, err error) {
+	var current *string
+	for _, field := range strings.Fields(arg) {
+		key, value, ok := strings.Cut(field, "=")
+		switch {
+		case ok && key == "branch":
+			branch, current = value, &branch
+		case ok && key == "workflow":
+			workflow, current = value, &workflow
+		case current != nil:
+			*current += " " + field
+		default:
+			return "", "", fmt.Errorf("invalid filter %q: use branch=NAME and/or workflow=NAME", field)
+		}
+	}
+	return branch, workflow, nil
+}
+
+// setRepos rebuilds the grid and completions for repos. Cards that stay
+// on the dashboard keep their state; the command fills new ones from
+// history.
+func (m DashboardModel) setRepos(repos []config.Repo) (DashboardModel, tea.Cmd) {
+	m.grid = m.grid.SetRepos(repos)
+	m.commandInput = m.commandInput.SetRepos(repos)
+	return m.SetSize(m.width, m.height), m.grid.LoadHistory()
+}
+
 func splitOwnerName(s string) []string {
 	for i := 0; i < len(s); i++ {
 		if s[i] == '/' {
@@ -291,10 +711,24 @@ func (m DashboardModel) View() string {
 			Bold(true)
 		titleText = "ghflow - " + profileStyle.Render(m.profileName)
 	}
+	if github.Offline() {
+		offlineStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		titleText += " " + offlineStyle.Render("[offline]")
+	}
+	if n := m.inbox.Count(); n > 0 {
+		inboxStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		titleText += " " + inboxStyle.Render(fmt.Sprintf("[inbox %d]", n))
+	}
 	title := titleStyle.Render(titleText)
 
-	// Grid
+	// Grid, or the inbox or scan results in its place
 	gridView := m.grid.View()
+	switch m.mode {
+	case ModeInbox:
+		gridView = m.inbox.View()
+	case ModeScan:
+		gridView = m.scan.View()
+	}
 
 	// Command input
 	cmdView := m.commandInput.View()
@@ -305,15 +739,27 @@ func (m DashboardModel) View() string {
 	if m.err != nil {
 		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
 		helpLine = errStyle.Render("error: " + m.err.Error())
+	} else if m.notice != "" {
+		noticeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+		helpLine = noticeStyle.Render(m.notice)
+	} else if m.mode == ModeInbox && m.inbox.Detail != nil {
+		helpLine = helpStyle.Render("j/k: scroll jobs | esc: back to inbox")
+	} else if m.mode == ModeInbox {
+		helpLine = helpStyle.Render("j/k: move | enter: view details | a: acknowledge | z: snooze 1h | r: refresh | esc: back")
+	} else if m.mode == ModeScan {
+		helpLine = helpStyle.Render("j/k: move | space: toggle | a: toggle all | enter: add selected | esc: cancel")
 	} else if m.mode == ModeGrid && m.grid.State == components.GridNavigating {
-		helpLine = helpStyle.Render("h/j/k/l: navigate | enter: focus | /: command | q: quit")
+		helpLine = helpStyle.Render("h/j/k/l: navigate | enter: focus | i: inbox | /: command | q: quit")
 	} else if m.mode == ModeGrid && m.grid.State == components.GridCardFocused {
 		// Check if we're viewing run details or run list
 		focusedCard := m.grid.SelectedCard()
-		if focusedCard != nil && focusedCard.State == components.CardRunDetail {
+		switch {
+		case focusedCard != nil && focusedCard.State == components.CardRunDetail:
 			helpLine = helpStyle.Render("j/k: scroll jobs | esc: back to runs | q: quit")
-		} else {
-			helpLine = helpStyle.Render("j/k: scroll runs | enter: view details | esc: unfocus | q: quit")
+		case focusedCard != nil && (focusedCard.State == components.CardStats || focusedCard.State == components.CardFlaky):
+			helpLine = helpStyle.Render("esc: back to runs | q: quit")
+		default:
+			helpLine = helpStyle.Render("j/k: scroll runs | enter: view details | s: stats | f: flaky jobs | esc: unfocus | q: quit")
 		}
 	}
 
@@ -0,0 +1,267 @@
+// Package web serves a read-only HTML dashboard that updates itself with
+// server-sent events.
+package web
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/poll"
+)
+
+const (
+	// runsPerCard is how many recent runs each card lists.
+	runsPerCard = 5
+
+	// keepAlive is how often an idle event stream gets a comment, so
+	// proxies don't time it out.
+	keepAlive = 30 * time.Second
+)
+
+//go:embed page.html
+var pageHTML string
+
+var page = template.Must(template.New("page").Parse(pageHTML))
+
+type pageData struct {
+	Title   string
+	Updated time.Time
+	Cards   []cardData
+}
+
+type cardData struct {
+	Repo   string
+	URL    string
+	Filter string
+	Status github.RunStatus
+	Icon   string
+	Error  string
+	Runs   []runData
+}
+
+type runData struct {
+	Workflow string
+	Number   int
+	Branch   string
+	Status   github.RunStatus
+	Icon     string
+	URL      string
+	Age      string
+}
+
+// Server polls repos and serves the dashboard, pushing the grid to every
+// open page after each poll.
+type Server struct {
+	title string
+	repos []config.Repo
+
+	mu      sync.Mutex
+	results []poll.Result
+	updated time.Time
+	grid    []byte // Last rendered grid
+	streams map[chan []byte]struct{}
+}
+
+func NewServer(title string, repos []config.Repo) *Server {
+	return &Server{
+		title:   title,
+		repos:   repos,
+		streams: map[chan []byte]struct{}{},
+	}
+}
+
+// Run polls every interval until ctx is done, starting straight away.
+func (s *Server) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		results := poll.FetchAll(ctx, s.repos)
+		if ctx.Err() != nil {
+			return
+		}
+		s.update(results, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update stores the results of a poll and sends the new grid to every
+// stream.
+func (s *Server) update(results []poll.Result, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = results
+	s.updated = now
+
+	var buf bytes.Buffer
+	if err := page.ExecuteTemplate(&buf, "grid", s.dataLocked(now)); err != nil {
+		return
+	}
+	s.grid = buf.Bytes()
+	for stream := range s.streams {
+		// A stream that's behind only needs the latest grid
+		select {
+		case <-stream:
+		default:
+		}
+		stream <- s.grid
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch r.URL.Path {
+	case "/":
+		s.servePage(w)
+	case "/events":
+		s.serveEvents(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) servePage(w http.ResponseWriter) {
+	s.mu.Lock()
+	data := s.dataLocked(time.Now())
+	s.mu.Unlock()
+
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	stream := make(chan []byte, 1)
+	s.mu.Lock()
+	s.streams[stream] = struct{}{}
+	if s.grid != nil {
+		stream <- s.grid
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, stream)
+		s.mu.Unlock()
+	}()
+
+	// Flush the headers so the page sees the stream open
+	flusher.Flush()
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case grid := <-stream:
+			if _, err := w.Write(event("grid", grid)); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// event formats an SSE event; every line of data needs its own field.
+func event(name string, data []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("event: " + name + "\n")
+	for _, line := range strings.Split(string(data), "\n") {
+		b.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	b.WriteString("\n")
+	return b.Bytes()
+}
+
+func (s *Server) dataLocked(now time.Time) pageData {
+	data := pageData{Title: s.title, Updated: s.updated}
+	for _, r := range s.results {
+		card := cardData{
+			Repo:   r.Repo.FullName(),
+			URL:    fmt.Sprintf("https://github.com/%s/actions", r.Repo.FullName()),
+			Status: r.Status(),
+		}
+		card.Icon = card.Status.Icon()
+		var filters []string
+		if r.Repo.Branch != "" {
+			filters = append(filters, "branch: "+r.Repo.Branch)
+		}
+		if r.Repo.Workflow != "" {
+			filters = append(filters, "workflow: "+r.Repo.Workflow)
+		}
+		card.Filter = strings.Join(filters, ", ")
+		if r.Err != nil {
+			card.Status = "error"
+			card.Icon = "[!]"
+			card.Error = r.Err.Error()
+		}
+		for i, run := range r.Runs {
+			if i == runsPerCard {
+				break
+			}
+			status := run.RunStatus()
+			url := run.HTMLURL
+			if url == "" {
+				url = card.URL
+			}
+			card.Runs = append(card.Runs, runData{
+				Workflow: run.WorkflowName,
+				Number:   run.RunNumber,
+				Branch:   run.HeadBranch,
+				Status:   status,
+				Icon:     status.Icon(),
+				URL:      url,
+				Age:      timeAgo(now, run.CreatedAt),
+			})
+		}
+		data.Cards = append(data.Cards, card)
+	}
+	return data
+}
+
+func timeAgo(now, t time.Time) string {
+	diff := now.Sub(t)
+	switch {
+	case t.IsZero():
+		return ""
+	case diff < time.Minute:
+		return "now"
+	case diff < time.Hour:
+		return fmt.Sprintf("%dm", int(diff.Minutes()))
+	case diff < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(diff.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(diff.Hours()/24))
+}
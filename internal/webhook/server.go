@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thesimpledev/ghflow/internal/github"
+)
+
+// maxPayloadSize matches the largest payload GitHub will deliver.
+const maxPayloadSize = 25 << 20
+
+// Event is a workflow_run or workflow_job delivery. Exactly one of Run and
+// Job is set.
+type Event struct {
+	Repo string // owner/name
+	Run  *github.WorkflowRun
+	Job  *github.Job
+}
+
+type payload struct {
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	WorkflowRun *github.WorkflowRun `json:"workflow_run"`
+	WorkflowJob *github.Job         `json:"workflow_job"`
+}
+
+// Server receives GitHub webhook deliveries, verifies their signature and
+// publishes workflow events on a channel.
+type Server struct {
+	addr   string
+	secret []byte
+	events chan Event
+}
+
+func NewServer(addr, secret string) (*Server, error) {
+	if secret == "" {
+		return nil, errors.New("webhook receiver needs a secret to verify deliveries")
+	}
+	return &Server{
+		addr:   addr,
+		secret: []byte(secret),
+		events: make(chan Event, 64),
+	}, nil
+}
+
+// Events returns the channel deliveries are published on.
+func (s *Server) Events() <-chan Event {
+	return s.events
+}
+
+// Start listens on the server's address and serves until ctx is done.
+// It returns once the listener is bound.
+func (s *Server) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "could not read body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.validSignature(r.Header.Get("X-Hub-Signature-256"), body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Header.Get("X-GitHub-Event") {
+	case "workflow_run", "workflow_job":
+	default:
+		// ping and anything else we didn't ask for
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	event := Event{Repo: p.Repository.FullName}
+	switch {
+	case p.WorkflowRun != nil:
+		if p.WorkflowRun.WorkflowName == "" {
+			p.WorkflowRun.WorkflowName = p.WorkflowRun.Name
+		}
+		event.Run = p.WorkflowRun
+	case p.WorkflowJob != nil:
+		event.Job = p.WorkflowJob
+	default:
+		http.Error(w, "payload has no workflow_run or workflow_job", http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case s.events <- event:
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		// Consumer is behind; GitHub can redeliver, polling will catch up
+		http.Error(w, "event queue full", http.StatusServiceUnavailable)
+	}
+}
+
+// validSignature checks an X-Hub-Signature-256 header ("sha256=<hex>")
+// against the HMAC of body.
+func (s *Server) validSignature(header string, body []byte) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testSecret = "It's a Secret to Everybody"
+
+// deliver posts a recorded payload from testdata the way GitHub would.
+func deliver(t *testing.T, s *Server, event, file, secret string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	s, err := NewServer("localhost:0", testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestWorkflowRunDelivery(t *testing.T) {
+	s := newTestServer(t)
+	if rec := deliver(t, s, "workflow_run", "workflow_run.json", testSecret); rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body)
+	}
+
+	event := <-s.Events()
+	if event.Repo != "Acme/App" || event.Run == nil || event.Job != nil {
+		t.Fatalf("event = %+v, want a run for Acme/App", event)
+	}
+	run := event.Run
+	if run.ID != 11823579463 || run.RunNumber != 482 || run.RunAttempt != 2 {
+		t.Errorf("id, number, attempt = %d, %d, %d", run.ID, run.RunNumber, run.RunAttempt)
+	}
+	if run.Status != "completed" || run.Conclusion != "failure" || run.HeadBranch != "main" {
+		t.Errorf("status, conclusion, branch = %q, %q, %q", run.Status, run.Conclusion, run.HeadBranch)
+	}
+	// workflow_run payloads carry no workflow_name; the run name stands in
+	if run.WorkflowName != "CI" {
+		t.Errorf("WorkflowName = %q, want CI", run.WorkflowName)
+	}
+	if run.Actor.Login != "octocat" || run.UpdatedAt.IsZero() {
+		t.Errorf("actor, updated = %q, %v", run.Actor.Login, run.UpdatedAt)
+	}
+}
+
+func TestWorkflowJobDelivery(t *testing.T) {
+	s := newTestServer(t)
+	if rec := deliver(t, s, "workflow_job", "workflow_job.json", testSecret); rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body)
+	}
+
+	event := <-s.Events()
+	if event.Repo != "Acme/App" || event.Job == nil || event.Run != nil {
+		t.Fatalf("event = %+v, want a job for Acme/App", event)
+	}
+	job := event.Job
+	if job.ID != 33025145890 || job.RunID != 11823579463 || job.RunAttempt != 2 {
+		t.Errorf("id, run, attempt = %d, %d, %d", job.ID, job.RunID, job.RunAttempt)
+	}
+	if job.Name != "test (ubuntu-latest)" || job.WorkflowName != "CI" || job.Conclusion != "failure" {
+		t.Errorf("name, workflow, conclusion = %q, %q, %q", job.Name, job.WorkflowName, job.Conclusion)
+	}
+}
+
+func TestIgnoredDeliveries(t *testing.T) {
+	s := newTestServer(t)
+
+	if rec := deliver(t, s, "ping", "ping.json", testSecret); rec.Code != http.StatusNoContent {
+		t.Errorf("ping: status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if rec := deliver(t, s, "workflow_run", "workflow_run.json", "wrong secret"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("bad signature: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	select {
+	case event := <-s.Events():
+		t.Errorf("published %+v, want nothing", event)
+	default:
+	}
+}
@@ -1,28 +1,38 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/thesimpledev/ghflow/internal/cli"
 	"github.com/thesimpledev/ghflow/internal/config"
+	"github.com/thesimpledev/ghflow/internal/control"
+	"github.com/thesimpledev/ghflow/internal/daemon"
 	"github.com/thesimpledev/ghflow/internal/github"
+	"github.com/thesimpledev/ghflow/internal/rpc"
 	"github.com/thesimpledev/ghflow/internal/tui"
+	"github.com/thesimpledev/ghflow/internal/webhook"
 )
 
 func main() {
-	if !github.IsGHInstalled() {
-		fmt.Fprintln(os.Stderr, "Error: gh CLI is not installed.")
-		fmt.Fprintln(os.Stderr, "Please install it from: https://cli.github.com/")
-		os.Exit(1)
+	if len(os.Args) > 1 {
+		if run, ok := cli.Lookup(os.Args[1]); ok {
+			os.Exit(run(os.Args[2:]))
+		}
 	}
 
-	if !github.IsAuthenticated() {
-		fmt.Fprintln(os.Stderr, "Error: gh CLI is not authenticated.")
-		fmt.Fprintln(os.Stderr, "Please run: gh auth login")
-		os.Exit(1)
-	}
+	webhookAddr := flag.String("webhook", "", "listen `address` for workflow_run/workflow_job webhooks (e.g. :8787)")
+	offline := flag.Bool("offline", false, "show cached state only, without any network calls")
+	noDaemon := flag.Bool("no-daemon", false, "poll directly even when a ghflow daemon is running")
+	flag.Parse()
+
+	github.SetOffline(*offline)
+	checkGH()
 
 	cfg, err := config.Load()
 	if err != nil {
@@ -30,10 +40,64 @@ func main() {
 		os.Exit(1)
 	}
 
-	app := tui.NewApp(cfg)
-	p := tea.NewProgram(app, tea.WithAltScreen())
+	github.SetRequestTimeout(time.Duration(cfg.RequestTimeout) * time.Second)
+
+	// Cancelled on exit so no gh process outlives the UI
+	ctx, cancel := context.WithCancel(context.Background())
+
+	app := tui.NewApp(ctx, cfg)
+
+	if *webhookAddr == "" {
+		*webhookAddr = cfg.WebhookListen
+	}
+	if *webhookAddr != "" {
+		secret := os.Getenv("GHFLOW_WEBHOOK_SECRET")
+		if secret == "" {
+			secret = cfg.WebhookSecret
+		}
+		srv, err := webhook.NewServer(*webhookAddr, secret)
+		if err == nil {
+			err = srv.Start(ctx)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting webhook receiver: %v\n", err)
+			os.Exit(1)
+		}
+		app = app.SetRunEvents(srv.Events())
+	}
+
+	if !*noDaemon && !github.Offline() {
+		if client, err := daemon.Dial(); err == nil {
+			defer client.Close()
+			app = app.SetDaemon(client)
+		}
+	}
 
-	if _, err := p.Run(); err != nil {
+	p := tea.NewProgram(app, tea.WithAltScreen(), tea.WithReportFocus(), tea.WithContext(ctx))
+
+	// The control socket is best effort: the dashboard works without it
+	if path, err := control.SocketPath(os.Getpid()); err == nil {
+		if ln, err := rpc.Listen(path); err == nil {
+			defer os.Remove(path)
+			go tui.ServeControl(ctx, p, ln)
+		}
+	}
+
+	_, err = p.Run()
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// checkGH exits unless gh is installed and logged in. Offline mode never
+// calls gh, so it skips the check.
+func checkGH() {
+	if github.Offline() {
+		return
+	}
+	if err := github.CheckCLI(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}